@@ -1,9 +1,13 @@
 package tinygate
 
 import (
+	"context"
+	"github.com/andyzhou/tinygate/define"
 	"github.com/andyzhou/tinygate/face"
 	"github.com/andyzhou/tinygate/iface"
+	"github.com/andyzhou/tinygate/json"
 	pb "github.com/andyzhou/tinygate/proto"
+	"time"
 )
 
 /*
@@ -16,6 +20,61 @@ import (
  * - receive response from gate server/sub service
  */
 
+//tls option for sub gate/service connect
+type TLSOption = define.TLSOption
+
+//queue option and stat of gate request or service response queue
+//policy is `define.OverflowOfXXX`
+type (
+	QueueOption = define.QueueOption
+	QueueStat = define.QueueStat
+)
+
+//balancer for pick one gate of the same service kind
+//implement `Pick` to plug in custom policy
+type Balancer = iface.IBalancer
+
+//sentinel errors of ctx api, compare with `errors.Is`
+var (
+	ErrInvalidParameter = define.ErrInvalidParameter
+	ErrNoGate = define.ErrNoGate
+	ErrGateDown = define.ErrGateDown
+	ErrNoClientNode = define.ErrNoClientNode
+	ErrQueueFull = define.ErrQueueFull
+	ErrTimeout = define.ErrTimeout
+	ErrCodecNotFound = define.ErrCodecNotFound
+	ErrGateClosed = define.ErrGateClosed
+	ErrCallNotFound = define.ErrCallNotFound
+	ErrGenCBExists = define.ErrGenCBExists
+)
+
+//codec of message data, registry bind message id with go type and codec
+//codec failed return `CodecError`, response error code return `RespError`
+type (
+	Codec = iface.ICodec
+	CodecRegistry = iface.ICodecRegistry
+	CodecError = define.CodecError
+	RespError = define.RespError
+)
+
+//construct codec registry
+func NewCodecRegistry() CodecRegistry {
+	return face.NewCodecRegistry()
+}
+
+//construct built-in codecs
+func NewProtoCodec() Codec {
+	return face.NewProtoCodec()
+}
+
+func NewJsonCodec() Codec {
+	return face.NewJsonCodec()
+}
+
+func NewMsgpackCodec() Codec {
+	return face.NewMsgpackCodec()
+}
+
 //client info
 type Client struct {
 	client iface.IClient
@@ -36,6 +95,14 @@ func (c *Client) Quit() {
 	c.client.Quit()
 }
 
+//shutdown gracefully
+//stop accepting new messages, flush queued messages of all gates,
+//close bind streams and wait for receive process exit.
+//return dropped count of queued messages if context done
+func (c *Client) Shutdown(ctx context.Context) (int, error) {
+	return c.client.Shutdown(ctx)
+}
+
 //set call back for received stream data from sub gate/service server
 //STEP-2
 func (c *Client) SetCBForStreamReceived(
@@ -60,16 +127,110 @@ func (c *Client) SetCBForGateServerUp(
 	return c.client.SetCBForGateServerUp(cb)
 }
 
+//set call back for node status changed of sub gate/service
+//maintain gate serves existing bound or sticky keys only
+func (c *Client) SetCBForGateStatus(
+			cb func(serviceKind, addr string, from, to pb.NodeStatus) bool,
+		) bool {
+	return c.client.SetCBForGateStatus(cb)
+}
+
+//set call back for sticky keys moved of persistent rule
+//moved is key -> new gate address, from is the downed gate address
+func (c *Client) SetCBForKeysMoved(
+			cb func(kind, from string, moved map[string]string) bool,
+		) bool {
+	return c.client.SetCBForKeysMoved(cb)
+}
+
+//set sticky table for persistent rule
+//default is in memory table, can be restored from snapshot
+func (c *Client) SetStickyTable(table iface.IStickyTable) bool {
+	return c.client.SetStickyTable(table)
+}
+
+//get sticky table of persistent rule
+func (c *Client) GetStickyTable() iface.IStickyTable {
+	return c.client.GetStickyTable()
+}
+
+//set static access auth for sub gate/service
+//STEP-5, optional, should be called before `AddGateServer`
+func (c *Client) SetAccessAuth(app, token string) bool {
+	return c.client.SetAccessAuth(app, token)
+}
+
+//set call back for get access auth by service kind
+//used for dynamic token, like hmac signed token
+func (c *Client) SetCBForAccessAuth(cb func(kind string) *pb.AccessAuth) bool {
+	return c.client.SetCBForAccessAuth(cb)
+}
+
+//set call back for async response
+//used for async general request without request cb
+func (c *Client) SetCBForAsyncResp(
+			cb func(from string, reqId uint64, resp *pb.GateResp) bool,
+		) bool {
+	return c.client.SetCBForAsyncResp(cb)
+}
+
+//set call back for request from sub service
+//used to answer sub service queries, like front end connect info,
+//return value of cb will be replied to sub service
+func (c *Client) SetCBForServiceReq(
+			cb func(from string, in *pb.ByteMessage) *pb.ByteMessage,
+		) bool {
+	return c.client.SetCBForServiceReq(cb)
+}
+
+//gen hmac signed access token
+func GenHmacToken(app, secret string) string {
+	return face.GenHmacToken(app, secret)
+}
+
 //set log option
 func (c *Client) SetLog(dir, tag string) bool {
 	return c.client.SetLog(dir, tag)
 }
 
 //add sub gate/service server
-//support multi gates
+//support multi gates, the first tag is node tag for sub service side,
+//sub service can send stream data by node identity `kind/tag`
 //STEP-5
-func (c *Client) AddGateServer(serviceKind, host string, port int) bool {
-	return c.client.AddGateServer(serviceKind, host, port)
+func (c *Client) AddGateServer(
+			serviceKind, host string,
+			port int,
+			tags ...string,
+		) bool {
+	return c.client.AddGateServer(serviceKind, host, port, tags...)
+}
+
+//set heart beat option for sub gate/service
+//gate down after `maxMiss` beats without any data from gate server,
+//gate follows rate of gate server if rate out of its `rate * maxMiss` window
+func (c *Client) SetHeartBeat(rate time.Duration, maxMiss int) bool {
+	return c.client.SetHeartBeat(rate, maxMiss)
+}
+
+//get heart beat round trip time of sub gate/service by address
+func (c *Client) GetGateRTT(address string) time.Duration {
+	return c.client.GetGateRTT(address)
+}
+
+//get front end connect registry
+//shared by all front ends and callbacks
+func (c *Client) GetConnRegistry() iface.IConnRegistry {
+	return c.client.GetConnRegistry()
+}
+
+//get player bind by front end connect id
+func (c *Client) GetBindByConn(connId uint32) *json.BindJson {
+	return c.client.GetBindByConn(connId)
+}
+
+//get player bind by player id
+func (c *Client) GetBindByPlayer(playerId int64) *json.BindJson {
+	return c.client.GetBindByPlayer(playerId)
 }
 
 //pick one sub gate/service by service kind
@@ -78,11 +239,147 @@ func (c *Client) PickGateServer(serviceKind string) iface.IGate {
 	return c.client.PickOneGateServer(serviceKind)
 }
 
+//add sub gate/service server with tls option
+//support mutual tls and certificate hot reload
+func (c *Client) AddGateServerWithTLS(
+			serviceKind, host string,
+			port int,
+			tlsOption *TLSOption,
+			tags ...string,
+		) bool {
+	return c.client.AddGateServerWithTLS(serviceKind, host, port, tlsOption, tags...)
+}
+
+//remove sub gate/service server by address
+func (c *Client) RemoveGateServer(address string) bool {
+	return c.client.RemoveGateServer(address)
+}
+
+//set routing rule for service kind
+//rule is `define.NodeRuleOfXXX`
+func (c *Client) SetRule(serviceKind string, rule int) bool {
+	return c.client.SetRule(serviceKind, rule)
+}
+
+//set custom balancer for service kind
+//used by pick, general request and cast by kind
+func (c *Client) SetBalancer(serviceKind string, balancer Balancer) bool {
+	return c.client.SetBalancer(serviceKind, balancer)
+}
+
+//set built-in balance policy for service kind
+//policy is `define.BalancerOfXXX`
+func (c *Client) SetBalancePolicy(serviceKind string, policy int) bool {
+	return c.client.SetBalancePolicy(serviceKind, policy)
+}
+
+//set sub gate/service weight for weighted balancer
+func (c *Client) SetGateWeight(address string, weight int) bool {
+	return c.client.SetGateWeight(address, weight)
+}
+
+//set default request timeout for service kind
+//used for ctx api without deadline, and the api without ctx
+func (c *Client) SetTimeout(serviceKind string, timeout time.Duration) bool {
+	return c.client.SetTimeout(serviceKind, timeout)
+}
+
+//set request queue option for all sub gate/service
+//overflow policy applied when queue is full
+func (c *Client) SetQueueOption(option *QueueOption) bool {
+	return c.client.SetQueueOption(option)
+}
+
+//set request queue option for one sub gate/service by address
+func (c *Client) SetGateQueueOption(address string, option *QueueOption) bool {
+	return c.client.SetGateQueueOption(address, option)
+}
+
+//get request queue stat of all sub gate/service, address -> stat
+func (c *Client) GetQueueStats() map[string]QueueStat {
+	return c.client.GetQueueStats()
+}
+
+//set call back for request queue crossing high water mark
+//over is false when queue depth fall back to half of high water mark
+func (c *Client) SetCBForQueueHighWater(
+			cb func(serviceKind, addr string, depth int, over bool) bool,
+		) bool {
+	return c.client.SetCBForQueueHighWater(cb)
+}
+
+//set codec registry for value api
+func (c *Client) SetCodecs(codecs CodecRegistry) bool {
+	return c.client.SetCodecs(codecs)
+}
+
+//add stream interceptor
+//run for outbound stream data and inbound stream data from sub gate/service,
+//first added is the outermost one, check `ReqMeta.Direction` for direction
+func (c *Client) AddStreamInterceptor(interceptor StreamInterceptor) bool {
+	return c.client.AddStreamInterceptor(interceptor)
+}
+
+//add outbound general request interceptor
+//first added is the outermost one
+func (c *Client) AddGenInterceptor(interceptor GenInterceptor) bool {
+	return c.client.AddGenInterceptor(interceptor)
+}
+
+//pick one sub gate/service by service kind and routing key
+func (c *Client) PickGateServerByKey(serviceKind, key string) iface.IGate {
+	return c.client.PickOneGateServerByKey(serviceKind, key)
+}
+
 //send gen sync request
 func (c *Client) SendGenReq(in *pb.GateReq) *pb.GateResp {
 	return c.client.SendGenReq(in)
 }
 
+//send gen sync request by routing key
+func (c *Client) SendGenReqByKey(in *pb.GateReq, key string) *pb.GateResp {
+	return c.client.SendGenReqByKey(in, key)
+}
+
+//send gen sync request with context
+//return `ErrNoGate`, `ErrGateDown`, `ErrTimeout` or remote error
+func (c *Client) SendGenReqCtx(
+			ctx context.Context,
+			in *pb.GateReq,
+		) (*pb.GateResp, error) {
+	return c.client.SendGenReqCtx(ctx, in)
+}
+
+//send gen sync request by routing key with context
+func (c *Client) SendGenReqByKeyCtx(
+			ctx context.Context,
+			in *pb.GateReq,
+			key string,
+		) (*pb.GateResp, error) {
+	return c.client.SendGenReqByKeyCtx(ctx, in, key)
+}
+
+//send gen async request
+//request acknowledged immediately, response delivered pass bind stream,
+//cb is optional, if nil, response will be passed to cb for async response.
+//return request id, zero means failed
+func (c *Client) SendAsyncGenReq(
+			in *pb.GateReq,
+			cb func(resp *pb.GateResp),
+		) uint64 {
+	return c.client.SendAsyncGenReq(in, cb)
+}
+
+//call one kind sub gate/service with stream mode, wait for reply
+//sub service should reply by `Service.Reply`
+func (c *Client) Call(
+			ctx context.Context,
+			kind string,
+			in *pb.ByteMessage,
+		) (*pb.ByteMessage, error) {
+	return c.client.Call(ctx, kind, in)
+}
+
 //cast stream data to one sub gate/service
 func (c *Client) CastData(
 			address string,
@@ -91,11 +388,57 @@ func (c *Client) CastData(
 	return c.client.CastData(address, in)
 }
 
+//cast stream data to one sub gate/service with context
+//return `ErrNoGate`, `ErrGateDown`, `ErrQueueFull` or `ErrTimeout`
+func (c *Client) CastDataCtx(
+			ctx context.Context,
+			address string,
+			in *pb.ByteMessage,
+		) error {
+	return c.client.CastDataCtx(ctx, address, in)
+}
+
+//cast data to one kind sub gate/service with context
+func (c *Client) CastDataByKindCtx(
+			ctx context.Context,
+			kind string,
+			in *pb.ByteMessage,
+		) error {
+	return c.client.CastDataByKindCtx(ctx, kind, in)
+}
+
+//send gen sync request with value encoded by codec registry
+//response data decoded into resp if resp is not nil
+func (c *Client) SendGenValue(
+			ctx context.Context,
+			kind string,
+			messageId uint32,
+			req, resp interface{},
+		) error {
+	return c.client.SendGenValue(ctx, kind, messageId, req, resp)
+}
+
+//cast value encoded by codec registry to one kind sub gate/service
+func (c *Client) CastValue(
+			ctx context.Context,
+			kind string,
+			messageId uint32,
+			v interface{},
+			connIds ...uint32,
+		) error {
+	return c.client.CastValue(ctx, kind, messageId, v, connIds...)
+}
+
 //cast data to one kind sub gate/service
 func (c *Client) CastDataByKind(kind string, in *pb.ByteMessage) bool {
 	return c.client.CastDataByKind(kind, in)
 }
 
+//cast data to one kind sub gate/service by routing key
+func (c *Client) CastDataByKey(kind, key string, in *pb.ByteMessage) bool {
+	return c.client.CastDataByKey(kind, key, in)
+}
+
 //cast data to all sub gate/service
 func (c *Client) CastDataToAll(in *pb.ByteMessage) bool {
 	return c.client.CastDataToAll(in)
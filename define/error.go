@@ -0,0 +1,54 @@
+package define
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+ * sentinel and structured errors
+ * - returned by ctx api of client and service
+ * - compare with `errors.Is` or `errors.As`
+ */
+
+var (
+	ErrInvalidParameter = errors.New("invalid parameter")
+	ErrNoGate = errors.New("no gate for service kind")
+	ErrGateDown = errors.New("gate server is down")
+	ErrNoClientNode = errors.New("no client node for address")
+	ErrQueueFull = errors.New("request queue is full")
+	ErrTimeout = errors.New("request timeout")
+	ErrCodecNotFound = errors.New("no codec for message id")
+	ErrGateClosed = errors.New("gate has been closed")
+	ErrCallNotFound = errors.New("no pending call for request")
+	ErrGenCBExists = errors.New("cb for general request has been set, use router instead")
+)
+
+//codec error of message data
+type CodecError struct {
+	Op string //encode or decode
+	MessageId uint32
+	Codec string //codec name
+	Err error
+}
+
+//error of general response
+type RespError struct {
+	Code int32
+	Message string
+}
+
+func (e *CodecError) Error() string {
+	if e.Codec == "" {
+		return fmt.Sprintf("%s message %d failed, %v", e.Op, e.MessageId, e.Err)
+	}
+	return fmt.Sprintf("%s message %d by %s codec failed, %v", e.Op, e.MessageId, e.Codec, e.Err)
+}
+
+func (e *CodecError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RespError) Error() string {
+	return fmt.Sprintf("response error, code:%d, message:%s", e.Code, e.Message)
+}
@@ -6,16 +6,124 @@ const (
 	NodeOptUnbind = 2
 )
 
+//node identity
+const (
+	NodeIdSeparator = "/" //client node identity is `kind/tag`
+)
+
 //node rule kind
 const (
 	NodeRuleOfHash = iota
 	NodeRuleOfPersistent
 )
 
+//balance policy kind
+const (
+	BalancerOfRoundRobin = iota
+	BalancerOfWeightedRoundRobin
+	BalancerOfRandom
+	BalancerOfLeastRequest
+	BalancerOfPowerOfTwo
+)
+
+//queue overflow policy
+const (
+	OverflowOfBlock = iota //block with timeout
+	OverflowOfDropNewest
+	OverflowOfDropOldest
+	OverflowOfReject
+)
+
+//interceptor direction
+const (
+	DirectionOfInbound = iota
+	DirectionOfOutbound
+)
+
+//codec
+const (
+	CodecOfProto = "proto"
+	CodecOfJson = "json"
+	CodecOfMsgpack = "msgpack"
+	CodecOpOfEncode = "encode"
+	CodecOpOfDecode = "decode"
+	CodecErrCode = -2 //error code of general response for codec failed
+	HandlerErrCode = -3 //error code of general response for handler failed
+	NotFoundErrCode = -4 //error code of general response for handler not found
+)
+
+//access auth
+const (
+	MetaKeyOfApp = "x-gate-app" //grpc metadata key for bind stream
+	MetaKeyOfToken = "x-gate-token"
+	AuthHmacMaxAge = 300 //xx seconds
+	AuthHmacSeparator = ":" //separator of app and timestamp in hmac sign
+)
+
+//async general request
+const (
+	MetaKeyOfReqId = "x-gate-req-id" //grpc metadata key for async request id
+	AsyncReqTimeout = 30 //xx seconds
+	AsyncRespErrCode = -1 //error code for async request failed at client side
+)
+
+//queue overflow
+const (
+	QueueBlockTimeout = 10 //xx seconds, block policy without timeout and context
+)
+
+//service shutdown
+const (
+	DrainCheckRate = 20 //xx milliseconds
+)
+
+//stream call
+const (
+	CallReqTimeout = 30 //xx seconds, used when context without deadline
+	CallCheckRate = 5 //xx seconds
+)
+
 //others
 const (
 	GateReqChanSize = 1024 * 5
 	GateBindTryTimes = 5
+	GateReconnectRate = 1 //xx seconds
+	TLSReloadRate = 10 //xx seconds
 	GateStatCheckRate = 5 //xx seconds
 	ResponseChanSize = 1024 * 5
-)
\ No newline at end of file
+	HeartBeatRate = 5 //xx seconds
+	HeartBeatMaxMiss = 3 //max missed beats before node down
+	HashRingReplicas = 100 //virtual nodes of one gate in hash ring
+	GateDefaultWeight = 1 //default weight of gate for weighted balancer
+	GateLatencyDecay = 0.2 //decay of gate latency moving average
+	GateReqTimeout = 10 //xx seconds, default timeout of request without deadline
+)
+
+//tcp front end
+const (
+	TcpHeaderSize = 8 //body length(4) + message id(4)
+	TcpMaxBodySize = 1024 * 1024 //1MB
+	ConnWriteChanSize = 1024
+	AcceptRetryMinDelay = 5 //xx milliseconds, for temporary accept error
+	AcceptRetryMaxDelay = 1000 //xx milliseconds
+)
+
+//websocket front end
+const (
+	WsPath = "/ws"
+	WsPingRate = 25 //xx seconds
+	WsPongWait = 60 //xx seconds
+	WsWriteWait = 10 //xx seconds
+	WsMaxMessageSize = 1024 * 1024 //1MB
+	WsBufferSize = 4096
+)
+
+//http front end
+const (
+	HttpHeaderOfApp = "X-Gate-App"
+	HttpHeaderOfToken = "X-Gate-Token"
+	HttpHeaderOfErrorCode = "X-Gate-Error-Code"
+	HttpHeaderOfErrorMessage = "X-Gate-Error-Message"
+	HttpParaOfAddress = "address"
+	HttpMaxBodySize = 1024 * 1024 //1MB
+)
@@ -0,0 +1,15 @@
+package define
+
+/*
+ * interceptor meta
+ * - used for client and service interceptor chain
+ * - connect info of intercepted request
+ */
+
+//request meta
+type ReqMeta struct {
+	Direction int //`DirectionOfXXX`
+	Kind string //service kind
+	RemoteAddr string //gate server address at client side, client node address at service side
+	App string //authenticated app of client node, service side only
+}
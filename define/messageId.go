@@ -10,4 +10,16 @@ package define
  	MessageIdOfBindOrUnbind //player node bind or unbind
 	 MessageIdOfHeartBeat
  	MessageIdOfClientClosed //tcp client disconnect
- )
\ No newline at end of file
+ 	MessageIdOfAsyncResp //async general response
+ 	MessageIdOfCallReq //stream call request
+ 	MessageIdOfCallResp //stream call response
+ 	MessageIdOfServiceReq //request from sub service to client node
+ 	MessageIdOfServiceResp //response from client node to sub service
+ 	MessageIdOfNodeStatus //node status of sub service
+ 	MessageIdOfCallCancel //stream call given up by client
+ )
+
+//max inter message id, end user message id should be bigger
+const (
+	MessageIdOfInterMax = 20
+)
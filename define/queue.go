@@ -0,0 +1,24 @@
+package define
+
+import "time"
+
+/*
+ * queue option and stat
+ * - used for gate request queue and service response queue
+ * - overflow policy when queue is full
+ */
+
+//queue option
+type QueueOption struct {
+	Policy int //overflow policy, `OverflowOfXXX`
+	BlockTimeout time.Duration //max wait for block policy, zero means until context done or `QueueBlockTimeout`
+	HighWater int //high water mark of queue depth, zero means disabled
+}
+
+//queue stat
+type QueueStat struct {
+	Depth int //current queued messages
+	Capacity int
+	Dropped uint64 //dropped messages by overflow policy
+	OverHighWater bool //depth over high water mark or not
+}
@@ -0,0 +1,15 @@
+package define
+
+/*
+ * tls option
+ * - used for gate client and server
+ */
+
+//tls option
+type TLSOption struct {
+	CertFile string //certificate file, optional for client side
+	KeyFile string //private key file, optional for client side
+	CAFile string //ca file, verify remote peer
+	ServerName string //server name override, client side only
+	RequireClientCert bool //mutual tls, server side only, CAFile is required
+}
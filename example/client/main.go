@@ -21,8 +21,22 @@ const (
 
 	//others
 	gateServerKind = "chat"
+	gateNodeTag = "chat-1" //node tag, unique of one kind
+
+	//typed general request
+	echoMessageId = 30
 )
 
+//typed general request and response
+type EchoReq struct {
+	Message string `json:"message"`
+}
+
+type EchoResp struct {
+	Message string `json:"message"`
+	Time int64 `json:"time"`
+}
+
 //cb for received stream data from gate server
 func cbForReceivedStreamData(from string, in *pb.ByteMessage) bool {
 	fmt.Println("cbForReceivedStreamData, from:", from, ", in:", string(in.Data))
@@ -65,7 +79,7 @@ func main()  {
 	c.SetLog("log", "client")
 
 	//add sub gate server
-	bRet := c.AddGateServer(gateServerKind, gateServer, gatePort)
+	bRet := c.AddGateServer(gateServerKind, gateServer, gatePort, gateNodeTag)
 	if !bRet {
 		log.Println("add gate server failed")
 		return
@@ -82,12 +96,10 @@ func main()  {
 	log.Println("stop client..")
 }
 
-//send general request to gate
+//send typed general request to gate
 func sendGenReqToGate(c *tinygate.Client)  {
 	var (
-		in = pb.GateReq{}
 		ticker = time.NewTicker(time.Second / 10)
-		messageData string
 	)
 
 	//defer
@@ -96,26 +108,22 @@ func sendGenReqToGate(c *tinygate.Client)  {
 	}()
 
 	//loop
-	messageIdStart := uint32(30)
-	messageIdEnd := 40
 	for {
 		select {
 		case <- ticker.C:
 			{
-				//set pb data
-				in.Service = gateServerKind
-				in.MessageId = uint32(rand.Intn(messageIdEnd))
-				if in.MessageId < messageIdStart {
-					in.MessageId = messageIdStart
+				//init typed request
+				req := EchoReq{
+					Message:fmt.Sprintf("echo-%d", time.Now().Unix()),
 				}
-				messageData = fmt.Sprintf("%d-%d", in.MessageId, time.Now().Unix())
-				in.Data = []byte(messageData)
 
 				//send general request to gate server
-				resp := c.SendGenReq(&in)
-				if resp != nil {
-					log.Println("client resp, resp:", resp)
+				resp, err := tinygate.Call[EchoReq, EchoResp](c, gateServerKind, echoMessageId, req)
+				if err != nil {
+					log.Println("client call failed, err:", err)
+					continue
 				}
+				log.Println("client resp, resp:", resp)
 			}
 		}
 	}
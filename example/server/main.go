@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/andyzhou/tinygate"
 	pb "github.com/andyzhou/tinygate/proto"
@@ -18,25 +19,40 @@ const (
 	//rpc service port
 	rpcPort = 7100
 	maxMessageId = 100
+
+	//typed general request
+	echoMessageId = 30
+	gateClientNodeId = "chat/chat-1" //node identity `kind/tag` of gate client
 )
 
+//typed general request and response
+type EchoReq struct {
+	Message string `json:"message"`
+}
+
+type EchoResp struct {
+	Message string `json:"message"`
+	Time int64 `json:"time"`
+}
+
 //cb stream request from gate server
 func cbForStreamReq(remoteAddr string, req *pb.ByteMessage) bool {
 	log.Println("cbForStreamReq, remoteAddr:", remoteAddr)
 	return true
 }
 
-//cb for the request from gate client side
+//typed handler for the request from gate client side
 //this for the sync request
-func cbForGenReq(in *pb.GateReq) *pb.GateResp {
-	log.Println("cbForGenReq, in messageId:", in.MessageId)
+func handleEcho(ctx context.Context, req EchoReq) (EchoResp, error) {
+	in := tinygate.GateReqFromContext(ctx)
+	log.Println("handleEcho, in messageId:", in.MessageId, ", message:", req.Message)
 
 	//init resp
-	resp := &pb.GateResp{
-		ErrorCode: 1,
-		ErrorMessage: "test",
+	resp := EchoResp{
+		Message: req.Message,
+		Time: time.Now().Unix(),
 	}
-	return resp
+	return resp, nil
 }
 
 func main() {
@@ -57,15 +73,23 @@ func main() {
 	//cb for stream data request process
 	s.SetCBForStreamReq(cbForStreamReq)
 
-	//cb for general request process
-	s.SetCBForGenReq(cbForGenReq)
+	//typed handler for general request process
+	err := tinygate.Handle(s, echoMessageId, handleEcho)
+	if err != nil {
+		log.Println("set handler failed, err:", err)
+		return
+	}
 
 	//wg add
 	wg.Add(1)
 
 	//start
 	log.Printf("start service,localhost:%d\n", rpcPort)
-	s.Start()
+	err = s.Start()
+	if err != nil {
+		log.Println("start service failed, err:", err)
+		return
+	}
 
 	//send data to client
 	go sendDataToGateClient(s)
@@ -98,7 +122,8 @@ func sendDataToGateClient(s *tinygate.Service) {
 				msg = fmt.Sprintf(msgPara, time.Now().Unix())
 				in.MessageId = uint32(messageId)
 				in.Data = []byte(msg)
-				s.SendStreamDataRespToAll(&in)
+				//send to gate client by node identity
+				s.SendStreamDataResp(&in, gateClientNodeId)
 			}
 		}
 	}
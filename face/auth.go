@@ -0,0 +1,178 @@
+package face
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/andyzhou/tinygate/define"
+	pb "github.com/andyzhou/tinygate/proto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ * authenticator face, implement of IAuthenticator
+ * - used at gate server side
+ * - static token, app -> token
+ * - hmac signed token, `timestamp.hex(hmac-sha256(secret, app + ":" + timestamp))`
+ */
+
+//static token authenticator
+type StaticTokenAuth struct {
+	tokenMap map[string]string //app -> token
+	sync.RWMutex
+}
+
+//hmac signed token authenticator
+type HmacTokenAuth struct {
+	secretMap map[string]string //app -> secret
+	maxAge time.Duration //max age of signed token
+	sync.RWMutex
+}
+
+/////////////////////////////////
+//construct for authenticators
+/////////////////////////////////
+
+func NewStaticTokenAuth() *StaticTokenAuth {
+	this := &StaticTokenAuth{
+		tokenMap:make(map[string]string),
+	}
+	return this
+}
+
+func NewHmacTokenAuth() *HmacTokenAuth {
+	this := &HmacTokenAuth{
+		secretMap:make(map[string]string),
+		maxAge:time.Second * define.AuthHmacMaxAge,
+	}
+	return this
+}
+
+//gen hmac signed token, used at gate client side
+func GenHmacToken(app, secret string) string {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	return fmt.Sprintf("%s.%s", timestamp, signHmac(app, secret, timestamp))
+}
+
+//////////////////////
+//api for StaticTokenAuth
+//////////////////////
+
+//add app token
+func (f *StaticTokenAuth) AddToken(app, token string) bool {
+	if app == "" || token == "" {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.tokenMap[app] = token
+	return true
+}
+
+//remove app token
+func (f *StaticTokenAuth) RemoveToken(app string) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.tokenMap, app)
+}
+
+//implement of IAuthenticator
+func (f *StaticTokenAuth) Authenticate(auth *pb.AccessAuth) (string, error) {
+	if auth == nil || auth.App == "" {
+		return "", errors.New("no access auth")
+	}
+	f.RLock()
+	token, ok := f.tokenMap[auth.App]
+	f.RUnlock()
+	if !ok || !hmac.Equal([]byte(token), []byte(auth.Token)) {
+		return "", errors.New("invalid access token")
+	}
+	return auth.App, nil
+}
+
+//////////////////////
+//api for HmacTokenAuth
+//////////////////////
+
+//add app secret
+func (f *HmacTokenAuth) AddSecret(app, secret string) bool {
+	if app == "" || secret == "" {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.secretMap[app] = secret
+	return true
+}
+
+//remove app secret
+func (f *HmacTokenAuth) RemoveSecret(app string) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.secretMap, app)
+}
+
+//set max age of signed token
+func (f *HmacTokenAuth) SetMaxAge(maxAge time.Duration) bool {
+	if maxAge <= 0 {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.maxAge = maxAge
+	return true
+}
+
+//implement of IAuthenticator
+func (f *HmacTokenAuth) Authenticate(auth *pb.AccessAuth) (string, error) {
+	if auth == nil || auth.App == "" {
+		return "", errors.New("no access auth")
+	}
+
+	//get secret
+	f.RLock()
+	secret, ok := f.secretMap[auth.App]
+	maxAge := f.maxAge
+	f.RUnlock()
+	if !ok {
+		return "", errors.New("invalid access app")
+	}
+
+	//check timestamp
+	parts := strings.SplitN(auth.Token, ".", 2)
+	if len(parts) != 2 {
+		return "", errors.New("invalid access token")
+	}
+	timestamp, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return "", errors.New("invalid access token")
+	}
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > maxAge || age < -maxAge {
+		return "", errors.New("access token expired")
+	}
+
+	//check sign
+	sign := signHmac(auth.App, secret, parts[0])
+	if !hmac.Equal([]byte(sign), []byte(parts[1])) {
+		return "", errors.New("invalid access token")
+	}
+	return auth.App, nil
+}
+
+////////////////
+//private func
+////////////////
+
+//sign app and timestamp with secret
+//separator keep `app1` + `23` and `app12` + `3` apart
+func signHmac(app, secret, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(app + define.AuthHmacSeparator + timestamp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,95 @@
+package face
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	pb "github.com/andyzhou/tinygate/proto"
+)
+
+func TestStaticTokenAuth(t *testing.T) {
+	auth := NewStaticTokenAuth()
+	if auth.AddToken("", "t1") || auth.AddToken("app", "") {
+		t.Fatal("add token with empty para should fail")
+	}
+	auth.AddToken("app", "t1")
+
+	app, err := auth.Authenticate(&pb.AccessAuth{App:"app", Token:"t1"})
+	if err != nil || app != "app" {
+		t.Fatalf("authenticate = %q, %v, want app", app, err)
+	}
+	if _, err = auth.Authenticate(&pb.AccessAuth{App:"app", Token:"t2"}); err == nil {
+		t.Fatal("wrong token should fail")
+	}
+	if _, err = auth.Authenticate(nil); err == nil {
+		t.Fatal("nil auth should fail")
+	}
+
+	//removed app
+	auth.RemoveToken("app")
+	if _, err = auth.Authenticate(&pb.AccessAuth{App:"app", Token:"t1"}); err == nil {
+		t.Fatal("removed app should fail")
+	}
+}
+
+func TestHmacTokenAuth(t *testing.T) {
+	auth := NewHmacTokenAuth()
+	auth.AddSecret("app", "s1")
+
+	//valid token
+	token := GenHmacToken("app", "s1")
+	app, err := auth.Authenticate(&pb.AccessAuth{App:"app", Token:token})
+	if err != nil || app != "app" {
+		t.Fatalf("authenticate = %q, %v, want app", app, err)
+	}
+
+	//wrong secret, unknown app, broken token
+	cases := []*pb.AccessAuth{
+		{App:"app", Token:GenHmacToken("app", "s2")},
+		{App:"other", Token:GenHmacToken("other", "s1")},
+		{App:"app", Token:"no-dot"},
+		{App:"app", Token:"abc.def"},
+		{App:"app"},
+		nil,
+	}
+	for i, c := range cases {
+		if _, err = auth.Authenticate(c); err == nil {
+			t.Fatalf("case %d should fail", i)
+		}
+	}
+}
+
+func TestHmacTokenAuthExpired(t *testing.T) {
+	auth := NewHmacTokenAuth()
+	auth.AddSecret("app", "s1")
+	auth.SetMaxAge(time.Minute)
+
+	//expired and far future token
+	for _, offset := range []time.Duration{-time.Hour, time.Hour} {
+		timestamp := strconv.FormatInt(time.Now().Add(offset).Unix(), 10)
+		token := fmt.Sprintf("%s.%s", timestamp, signHmac("app", "s1", timestamp))
+		if _, err := auth.Authenticate(&pb.AccessAuth{App:"app", Token:token}); err == nil {
+			t.Fatalf("token with offset %v should be expired", offset)
+		}
+	}
+}
+
+func TestHmacTokenAuthSeparator(t *testing.T) {
+	//`app1` + `23` and `app12` + `3` should not share sign
+	if signHmac("app1", "s1", "23") == signHmac("app12", "s1", "3") {
+		t.Fatal("sign of different app and timestamp should differ")
+	}
+
+	//token of one app can't be used by app with shifted name
+	auth := NewHmacTokenAuth()
+	auth.AddSecret("app", "s1")
+	auth.AddSecret("app1", "s1")
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	sign := signHmac("app1", "s1", timestamp[1:])
+	token := fmt.Sprintf("%s.%s", timestamp, sign)
+	if _, err := auth.Authenticate(&pb.AccessAuth{App:"app", Token:token}); err == nil {
+		t.Fatal("shifted sign should fail")
+	}
+}
@@ -0,0 +1,197 @@
+package face
+
+import (
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/iface"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+ * balancer face, implement of IBalancer
+ * - used at gate client side
+ * - round robin, weighted round robin, random,
+ *   least request and power of two choices
+ * - live stats come from gate, in flight count and latency
+ */
+
+//round robin balancer
+type RoundRobinBalancer struct {
+	counter uint64
+}
+
+//weighted round robin balancer, smooth mode
+type WeightedRoundRobinBalancer struct {
+	currentMap map[string]int //gate address -> current weight
+	sync.Mutex
+}
+
+//random balancer
+type RandomBalancer struct {
+}
+
+//least request balancer
+type LeastRequestBalancer struct {
+}
+
+//power of two choices balancer
+type PowerOfTwoBalancer struct {
+}
+
+//construct balancer by policy
+//policy is `define.BalancerOfXXX`
+func NewBalancer(policy int) iface.IBalancer {
+	switch policy {
+	case define.BalancerOfRoundRobin:
+		return NewRoundRobinBalancer()
+	case define.BalancerOfWeightedRoundRobin:
+		return NewWeightedRoundRobinBalancer()
+	case define.BalancerOfRandom:
+		return NewRandomBalancer()
+	case define.BalancerOfLeastRequest:
+		return NewLeastRequestBalancer()
+	case define.BalancerOfPowerOfTwo:
+		return NewPowerOfTwoBalancer()
+	}
+	return nil
+}
+
+/////////////////////////////////
+//construct for each balancer
+/////////////////////////////////
+
+func NewRoundRobinBalancer() *RoundRobinBalancer {
+	this := &RoundRobinBalancer{}
+	return this
+}
+
+func NewWeightedRoundRobinBalancer() *WeightedRoundRobinBalancer {
+	this := &WeightedRoundRobinBalancer{
+		currentMap:make(map[string]int),
+	}
+	return this
+}
+
+func NewRandomBalancer() *RandomBalancer {
+	this := &RandomBalancer{}
+	return this
+}
+
+func NewLeastRequestBalancer() *LeastRequestBalancer {
+	this := &LeastRequestBalancer{}
+	return this
+}
+
+func NewPowerOfTwoBalancer() *PowerOfTwoBalancer {
+	this := &PowerOfTwoBalancer{}
+	return this
+}
+
+//////////////////////
+//implement of IBalancer
+//////////////////////
+
+//pick gate in turn
+func (b *RoundRobinBalancer) Pick(key string, gates []iface.IGate) iface.IGate {
+	if len(gates) <= 0 {
+		return nil
+	}
+	idx := atomic.AddUint64(&b.counter, 1) - 1
+	return gates[idx % uint64(len(gates))]
+}
+
+//pick gate by weight, smooth weighted round robin like nginx
+func (b *WeightedRoundRobinBalancer) Pick(key string, gates []iface.IGate) iface.IGate {
+	var (
+		best iface.IGate
+		bestWeight, total int
+	)
+	if len(gates) <= 0 {
+		return nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	for _, gate := range gates {
+		weight := gate.GetWeight()
+		if weight <= 0 {
+			continue
+		}
+		total += weight
+		current := b.currentMap[gate.GetAddress()] + weight
+		b.currentMap[gate.GetAddress()] = current
+		if best == nil || current > bestWeight {
+			best = gate
+			bestWeight = current
+		}
+	}
+	if best != nil {
+		b.currentMap[best.GetAddress()] -= total
+	}
+
+	//clean removed gates
+	if len(b.currentMap) > len(gates) {
+		currentMap := make(map[string]int, len(gates))
+		for _, gate := range gates {
+			currentMap[gate.GetAddress()] = b.currentMap[gate.GetAddress()]
+		}
+		b.currentMap = currentMap
+	}
+	return best
+}
+
+//pick random gate
+func (b *RandomBalancer) Pick(key string, gates []iface.IGate) iface.IGate {
+	if len(gates) <= 0 {
+		return nil
+	}
+	return gates[rand.Intn(len(gates))]
+}
+
+//pick gate with least in flight requests
+func (b *LeastRequestBalancer) Pick(key string, gates []iface.IGate) iface.IGate {
+	var (
+		best iface.IGate
+		bestInFlight int64
+	)
+	for _, gate := range gates {
+		inFlight := gate.GetInFlight()
+		if best == nil || inFlight < bestInFlight {
+			best = gate
+			bestInFlight = inFlight
+		}
+	}
+	return best
+}
+
+//pick two random gates, use the one with lower load
+//load is in flight requests multiply by latency
+func (b *PowerOfTwoBalancer) Pick(key string, gates []iface.IGate) iface.IGate {
+	if len(gates) <= 0 {
+		return nil
+	}
+	if len(gates) == 1 {
+		return gates[0]
+	}
+
+	//pick two different gates
+	i := rand.Intn(len(gates))
+	j := rand.Intn(len(gates) - 1)
+	if j >= i {
+		j++
+	}
+	if b.getLoad(gates[j]) < b.getLoad(gates[i]) {
+		return gates[j]
+	}
+	return gates[i]
+}
+
+//get load of gate
+func (b *PowerOfTwoBalancer) getLoad(gate iface.IGate) float64 {
+	latency := float64(gate.GetLatency())
+	if latency <= 0 {
+		latency = 1
+	}
+	return float64(gate.GetInFlight() + 1) * latency
+}
@@ -0,0 +1,145 @@
+package face
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/iface"
+)
+
+//gate with fixed stats, only methods used by balancers
+type testGate struct {
+	iface.IGate
+	address string
+	weight int
+	inFlight int64
+	latency time.Duration
+}
+
+func (g *testGate) GetAddress() string { return g.address }
+func (g *testGate) GetWeight() int { return g.weight }
+func (g *testGate) GetInFlight() int64 { return g.inFlight }
+func (g *testGate) GetLatency() time.Duration { return g.latency }
+
+func newTestGates(weights ...int) []iface.IGate {
+	gates := make([]iface.IGate, 0, len(weights))
+	for i, weight := range weights {
+		gates = append(gates, &testGate{
+			address:string(rune('a' + i)),
+			weight:weight,
+		})
+	}
+	return gates
+}
+
+func TestNewBalancer(t *testing.T) {
+	policies := []int{
+		define.BalancerOfRoundRobin,
+		define.BalancerOfWeightedRoundRobin,
+		define.BalancerOfRandom,
+		define.BalancerOfLeastRequest,
+		define.BalancerOfPowerOfTwo,
+	}
+	for _, policy := range policies {
+		balancer := NewBalancer(policy)
+		if balancer == nil {
+			t.Fatalf("policy %d got nil balancer", policy)
+		}
+		if balancer.Pick("", nil) != nil {
+			t.Fatalf("policy %d pick from empty gates should be nil", policy)
+		}
+	}
+	if NewBalancer(-1) != nil {
+		t.Fatal("unknown policy should be nil")
+	}
+}
+
+func TestRoundRobinBalancer(t *testing.T) {
+	gates := newTestGates(1, 1, 1)
+	balancer := NewRoundRobinBalancer()
+	for i := 0; i < 6; i++ {
+		got := balancer.Pick("", gates)
+		if got != gates[i % 3] {
+			t.Fatalf("pick %d got %s, want %s", i, got.GetAddress(), gates[i % 3].GetAddress())
+		}
+	}
+}
+
+func TestWeightedRoundRobinBalancer(t *testing.T) {
+	gates := newTestGates(5, 1, 1, 0)
+	balancer := NewWeightedRoundRobinBalancer()
+
+	//one round is sum of weights
+	hits := make(map[string]int)
+	for i := 0; i < 7; i++ {
+		hits[balancer.Pick("", gates).GetAddress()]++
+	}
+	if hits["a"] != 5 || hits["b"] != 1 || hits["c"] != 1 || hits["d"] != 0 {
+		t.Fatalf("hits = %v, want a:5 b:1 c:1", hits)
+	}
+
+	//smooth, heavy gate not picked in a row for whole round
+	seq := ""
+	for i := 0; i < 7; i++ {
+		seq += balancer.Pick("", gates).GetAddress()
+	}
+	if seq == "aaaaabc" {
+		t.Fatalf("pick sequence %s is not smooth", seq)
+	}
+
+	//removed gate should be cleaned
+	balancer.Pick("", gates[:2])
+	if len(balancer.currentMap) != 2 {
+		t.Fatalf("current map size = %d, want 2", len(balancer.currentMap))
+	}
+
+	//all zero weight
+	if balancer.Pick("", newTestGates(0, 0)) != nil {
+		t.Fatal("zero weight gates should pick nil")
+	}
+}
+
+func TestRandomBalancer(t *testing.T) {
+	gates := newTestGates(1, 1)
+	balancer := NewRandomBalancer()
+	hits := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		hits[balancer.Pick("", gates).GetAddress()]++
+	}
+	if hits["a"] <= 0 || hits["b"] <= 0 {
+		t.Fatalf("hits = %v, want both gates picked", hits)
+	}
+}
+
+func TestLeastRequestBalancer(t *testing.T) {
+	gates := newTestGates(1, 1, 1)
+	gates[0].(*testGate).inFlight = 3
+	gates[1].(*testGate).inFlight = 1
+	gates[2].(*testGate).inFlight = 2
+	if got := NewLeastRequestBalancer().Pick("", gates); got != gates[1] {
+		t.Fatalf("got %s, want b", got.GetAddress())
+	}
+}
+
+func TestPowerOfTwoBalancer(t *testing.T) {
+	balancer := NewPowerOfTwoBalancer()
+
+	//single gate
+	single := newTestGates(1)
+	if balancer.Pick("", single) != single[0] {
+		t.Fatal("single gate should be picked")
+	}
+
+	//lower load wins between two
+	gates := newTestGates(1, 1)
+	gates[0].(*testGate).inFlight = 10
+	gates[0].(*testGate).latency = time.Millisecond * 10
+	gates[1].(*testGate).inFlight = 1
+	gates[1].(*testGate).latency = time.Millisecond
+	for i := 0; i < 20; i++ {
+		if got := balancer.Pick("", gates); got != gates[1] {
+			t.Fatalf("got %s, want b", got.GetAddress())
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package face
+
+import (
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/json"
+	"sync"
+)
+
+/*
+ * bind face, implement of IBind
+ * - used at gate client side
+ * - bind player and front end connect id
+ * - pin player to sub service node tag by service kind
+ * - bind or unbind notify from sub service pass stream
+ */
+
+//face info
+type Bind struct {
+	connMap map[uint32]*json.BindJson //connId -> BindJson
+	playerMap map[int64]*json.BindJson //playerId -> BindJson
+	sync.RWMutex
+}
+
+//construct
+func NewBind() *Bind {
+	//self init
+	this := &Bind{
+		connMap:make(map[uint32]*json.BindJson),
+		playerMap:make(map[int64]*json.BindJson),
+	}
+	return this
+}
+
+//////////////////////
+//implement of IBind
+//////////////////////
+
+//bind or unbind by opt
+func (f *Bind) BindOrUnbind(in *json.BindJson) bool {
+	if in == nil || in.ConnId == 0 {
+		return false
+	}
+	switch in.Opt {
+	case define.NodeOptBind:
+		return f.bind(in)
+	case define.NodeOptUnbind:
+		return f.unbind(in)
+	}
+	return false
+}
+
+//remove bind by connect id
+func (f *Bind) RemoveByConn(connId uint32) bool {
+	f.Lock()
+	defer f.Unlock()
+	old, ok := f.connMap[connId]
+	if !ok {
+		return false
+	}
+	delete(f.connMap, connId)
+	if old.PlayerId > 0 {
+		delete(f.playerMap, old.PlayerId)
+	}
+	return true
+}
+
+//get bind by connect id, return copy
+func (f *Bind) GetByConn(connId uint32) *json.BindJson {
+	f.RLock()
+	defer f.RUnlock()
+	v, ok := f.connMap[connId]
+	if !ok {
+		return nil
+	}
+	return f.copy(v)
+}
+
+//get bind by player id, return copy
+func (f *Bind) GetByPlayer(playerId int64) *json.BindJson {
+	f.RLock()
+	defer f.RUnlock()
+	v, ok := f.playerMap[playerId]
+	if !ok {
+		return nil
+	}
+	return f.copy(v)
+}
+
+//get bound node tag by connect id and service kind
+func (f *Bind) GetNode(connId uint32, kind string) string {
+	f.RLock()
+	defer f.RUnlock()
+	v, ok := f.connMap[connId]
+	if !ok {
+		return ""
+	}
+	return v.Nodes[kind]
+}
+
+////////////////
+//private func
+////////////////
+
+//bind player and nodes
+//nodes of the same connect will be merged
+func (f *Bind) bind(in *json.BindJson) bool {
+	f.Lock()
+	defer f.Unlock()
+
+	//get or init bind
+	v, ok := f.connMap[in.ConnId]
+	if !ok {
+		v = json.NewBindJson()
+		v.Opt = define.NodeOptBind
+		v.ConnId = in.ConnId
+		f.connMap[in.ConnId] = v
+	}
+
+	//player changed, remove old player index
+	if in.PlayerId > 0 && v.PlayerId != in.PlayerId {
+		if v.PlayerId > 0 {
+			delete(f.playerMap, v.PlayerId)
+		}
+		//remove old connect of the same player
+		old, isOk := f.playerMap[in.PlayerId]
+		if isOk && old.ConnId != in.ConnId {
+			delete(f.connMap, old.ConnId)
+		}
+		v.PlayerId = in.PlayerId
+		f.playerMap[in.PlayerId] = v
+	}
+
+	//merge nodes
+	for kind, tag := range in.Nodes {
+		v.Nodes[kind] = tag
+	}
+	return true
+}
+
+//unbind player or nodes
+//if nodes is empty, remove the whole bind
+func (f *Bind) unbind(in *json.BindJson) bool {
+	f.Lock()
+	defer f.Unlock()
+
+	//get bind
+	v, ok := f.connMap[in.ConnId]
+	if !ok {
+		return false
+	}
+
+	//remove assigned nodes only
+	if len(in.Nodes) > 0 {
+		for kind := range in.Nodes {
+			delete(v.Nodes, kind)
+		}
+		return true
+	}
+
+	//remove whole bind
+	delete(f.connMap, in.ConnId)
+	if v.PlayerId > 0 {
+		delete(f.playerMap, v.PlayerId)
+	}
+	return true
+}
+
+//copy bind
+func (f *Bind) copy(v *json.BindJson) *json.BindJson {
+	result := json.NewBindJson()
+	result.Opt = v.Opt
+	result.ConnId = v.ConnId
+	result.PlayerId = v.PlayerId
+	for kind, tag := range v.Nodes {
+		result.Nodes[kind] = tag
+	}
+	return result
+}
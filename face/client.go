@@ -1,12 +1,16 @@
 package face
 
 import (
+	"context"
 	"fmt"
 	"github.com/andyzhou/tinygate/define"
 	"github.com/andyzhou/tinygate/iface"
+	"github.com/andyzhou/tinygate/json"
 	pb "github.com/andyzhou/tinygate/proto"
 	"log"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -22,11 +26,29 @@ import (
 //client info
 type Client struct {
 	gateMap map[string]iface.IGate //running gate server map, serverAddress -> Gate
+	registry iface.IConnRegistry //front end connect registry
+	bind iface.IBind //player bind
+	ruleMap map[string]int //routing rule map, serviceKind -> rule
+	ringMap map[string]*HashRing //hash ring map, serviceKind -> HashRing
+	sticky iface.IStickyTable //sticky table for persistent rule
+	balancerMap map[string]iface.IBalancer //balancer map, serviceKind -> IBalancer
+	timeoutMap map[string]time.Duration //default request timeout map, serviceKind -> timeout
+	interceptor iface.IInterceptor //interceptor chain, shared by all gates
+	codecs iface.ICodecRegistry //codec registry for value api, optional
+	heartBeatRate time.Duration //heart beat rate for gates
+	heartBeatMaxMiss int //max missed heart beats for gates
 	cbForStreamReceived func(from string, in *pb.ByteMessage) bool //call back for received data
 	cbForGateServerDown func(kind string, addr string) bool //call back for gate server down
 	cbForGateServerUp func(kind string, addr string) bool //call back for gate server up
+	cbForGateStatus func(kind, addr string, from, to pb.NodeStatus) bool //call back for gate node status changed
+	cbForQueueHighWater func(kind, addr string, depth int, over bool) bool //call back for request queue high water
+	queueOption *define.QueueOption //request queue option for gates, optional
+	cbForKeysMoved func(kind, from string, moved map[string]string) bool //call back for sticky keys moved
+	cbForAccessAuth func(kind string) *pb.AccessAuth //call back for get access auth
+	cbForAsyncResp func(from string, reqId uint64, resp *pb.GateResp) bool //call back for async response
+	cbForServiceReq func(from string, in *pb.ByteMessage) *pb.ByteMessage //call back for sub service request
 	closeChan chan bool
-	sync.Mutex `internal data locker`
+	sync.RWMutex `internal data locker`
 }
 
 //construct
@@ -35,9 +57,22 @@ func NewClient() *Client {
 	//self init
 	this := &Client{
 		gateMap:make(map[string]iface.IGate),
+		registry:NewConnRegistry(),
+		bind:NewBind(),
+		ruleMap:make(map[string]int),
+		ringMap:make(map[string]*HashRing),
+		sticky:NewStickyTable(),
+		balancerMap:make(map[string]iface.IBalancer),
+		timeoutMap:make(map[string]time.Duration),
+		interceptor:NewInterceptor(),
+		heartBeatRate:time.Second * define.HeartBeatRate,
+		heartBeatMaxMiss:define.HeartBeatMaxMiss,
 		closeChan:make(chan bool, 1),
 	}
 
+	//notify sub services when front end connect closed
+	this.registry.AddCBForConnClosed(this.clientClosed)
+
 	//spawn main process
 	go this.runMainProcess()
 
@@ -53,18 +88,68 @@ func (c *Client) Quit() {
 		}
 	}()
 
+	//close front end connects
+	c.registry.Quit()
+
 	//clean gate map
-	if c.gateMap != nil {
-		for k, gate := range c.gateMap {
-			gate.Quit()
-			delete(c.gateMap, k)
-		}
+	for _, gate := range c.removeAllGates() {
+		gate.Quit()
 	}
 
 	//send to close chan
 	c.closeChan <- true
 }
 
+//shutdown gracefully
+//flush queued requests of all gates and close bind streams,
+//return total dropped count of queued requests if context done
+func (c *Client) Shutdown(ctx context.Context) (int, error) {
+	var (
+		dropped int64
+		err error
+		wg sync.WaitGroup
+		locker sync.Mutex
+	)
+
+	//basic check
+	if ctx == nil {
+		return 0, define.ErrInvalidParameter
+	}
+
+	//shutdown gates in parallel
+	for _, gate := range c.removeAllGates() {
+		wg.Add(1)
+		go func(gate iface.IGate) {
+			defer wg.Done()
+			subDropped, subErr := gate.Shutdown(ctx)
+			atomic.AddInt64(&dropped, int64(subDropped))
+			if subErr != nil {
+				locker.Lock()
+				if err == nil {
+					err = subErr
+				}
+				locker.Unlock()
+			}
+		}(gate)
+	}
+	wg.Wait()
+
+	//close front end connects
+	c.registry.Quit()
+
+	//send to close chan
+	select {
+	case c.closeChan <- true:
+	default:
+	}
+	return int(dropped), err
+}
+
+//get front end connect registry
+func (c *Client) GetConnRegistry() iface.IConnRegistry {
+	return c.registry
+}
+
 //set call back for received stream data from server side
 //STEP-3
 func (c *Client) SetCBForStreamReceived(
@@ -98,6 +183,111 @@ func (c *Client) SetCBForGateServerUp(cb func(kind, addr string) bool) bool {
 	return true
 }
 
+//set call back for gate node status changed
+//status is up after connected, then advertised by sub service
+func (c *Client) SetCBForGateStatus(
+				cb func(kind, addr string, from, to pb.NodeStatus) bool,
+			) bool {
+	if cb == nil || c.cbForGateStatus != nil {
+		return false
+	}
+	c.cbForGateStatus = cb
+	return true
+}
+
+//set call back for sticky keys moved
+//moved is key -> new gate address, from is the downed gate address
+func (c *Client) SetCBForKeysMoved(
+				cb func(kind, from string, moved map[string]string) bool,
+			) bool {
+	if cb == nil || c.cbForKeysMoved != nil {
+		return false
+	}
+	c.cbForKeysMoved = cb
+	return true
+}
+
+//set call back for get access auth by service kind
+//used for bind stream and general request without auth
+func (c *Client) SetCBForAccessAuth(cb func(kind string) *pb.AccessAuth) bool {
+	if cb == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.cbForAccessAuth = cb
+	for _, gate := range c.gateMap {
+		gate.SetCBForAccessAuth(cb)
+	}
+	return true
+}
+
+//set call back for async response
+//used for async general request without request cb
+func (c *Client) SetCBForAsyncResp(
+				cb func(from string, reqId uint64, resp *pb.GateResp) bool,
+			) bool {
+	if cb == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.cbForAsyncResp = cb
+	for _, gate := range c.gateMap {
+		gate.SetCBForAsyncResp(cb)
+	}
+	return true
+}
+
+//set call back for request from sub service
+//return value of cb will be replied to sub service
+func (c *Client) SetCBForServiceReq(
+				cb func(from string, in *pb.ByteMessage) *pb.ByteMessage,
+			) bool {
+	if cb == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.cbForServiceReq = cb
+	for _, gate := range c.gateMap {
+		gate.SetCBForServiceReq(cb)
+	}
+	return true
+}
+
+//set static access auth for all service kinds
+func (c *Client) SetAccessAuth(app, token string) bool {
+	if app == "" {
+		return false
+	}
+	return c.SetCBForAccessAuth(func(kind string) *pb.AccessAuth {
+		return &pb.AccessAuth{
+			App:app,
+			Token:token,
+		}
+	})
+}
+
+//set sticky table for persistent rule
+//default is in memory table
+func (c *Client) SetStickyTable(table iface.IStickyTable) bool {
+	if table == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.sticky = table
+	return true
+}
+
+//get sticky table
+func (c *Client) GetStickyTable() iface.IStickyTable {
+	c.RLock()
+	defer c.RUnlock()
+	return c.sticky
+}
+
 //set log option
 //STEP-5, optional
 func (c *Client) SetLog(dir, tag string) bool {
@@ -109,6 +299,190 @@ func (c *Client) SetLog(dir, tag string) bool {
 	return true
 }
 
+//set heart beat option for all gates
+//gate will be down after `maxMiss` beats without any data from gate server
+func (c *Client) SetHeartBeat(rate time.Duration, maxMiss int) bool {
+	if rate <= 0 || maxMiss <= 0 {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.heartBeatRate = rate
+	c.heartBeatMaxMiss = maxMiss
+	for _, gate := range c.gateMap {
+		gate.SetHeartBeat(rate, maxMiss)
+	}
+	return true
+}
+
+//get heart beat round trip time of gate by address
+func (c *Client) GetGateRTT(address string) time.Duration {
+	gate := c.getGateByAddr(address)
+	if gate == nil {
+		return 0
+	}
+	return gate.GetRTT()
+}
+
+//get player bind by connect id
+func (c *Client) GetBindByConn(connId uint32) *json.BindJson {
+	return c.bind.GetByConn(connId)
+}
+
+//get player bind by player id
+func (c *Client) GetBindByPlayer(playerId int64) *json.BindJson {
+	return c.bind.GetByPlayer(playerId)
+}
+
+//set routing rule for service kind
+//rule is `define.NodeRuleOfXXX`
+func (c *Client) SetRule(serviceKind string, rule int) bool {
+	if serviceKind == "" {
+		return false
+	}
+	if rule != define.NodeRuleOfHash && rule != define.NodeRuleOfPersistent {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.ruleMap[serviceKind] = rule
+	return true
+}
+
+//set balancer for service kind
+//used when no routing rule or routing key
+func (c *Client) SetBalancer(serviceKind string, balancer iface.IBalancer) bool {
+	if serviceKind == "" || balancer == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.balancerMap[serviceKind] = balancer
+	return true
+}
+
+//set built-in balance policy for service kind
+//policy is `define.BalancerOfXXX`
+func (c *Client) SetBalancePolicy(serviceKind string, policy int) bool {
+	balancer := NewBalancer(policy)
+	if balancer == nil {
+		return false
+	}
+	return c.SetBalancer(serviceKind, balancer)
+}
+
+//set gate weight for weighted balancer
+func (c *Client) SetGateWeight(address string, weight int) bool {
+	gate := c.getGateByAddr(address)
+	if gate == nil {
+		return false
+	}
+	return gate.SetWeight(weight)
+}
+
+//set request queue option for all gates
+func (c *Client) SetQueueOption(option *define.QueueOption) bool {
+	//check option by a temp guard
+	if !NewQueueGuard().SetOption(option) {
+		return false
+	}
+
+	//sync with locker
+	c.Lock()
+	defer c.Unlock()
+	queueOption := *option
+	c.queueOption = &queueOption
+	for _, gate := range c.gateMap {
+		gate.SetQueueOption(c.queueOption)
+	}
+	return true
+}
+
+//set request queue option for one gate by address
+func (c *Client) SetGateQueueOption(address string, option *define.QueueOption) bool {
+	gate := c.getGateByAddr(address)
+	if gate == nil {
+		return false
+	}
+	return gate.SetQueueOption(option)
+}
+
+//get request queue stat of all gates, address -> stat
+func (c *Client) GetQueueStats() map[string]define.QueueStat {
+	result := make(map[string]define.QueueStat)
+	for _, gate := range c.getAllGates() {
+		result[gate.GetAddress()] = gate.GetQueueStat()
+	}
+	return result
+}
+
+//set call back for request queue of gate crossing high water mark
+//should be called before `AddGateServer`
+func (c *Client) SetCBForQueueHighWater(
+				cb func(kind, addr string, depth int, over bool) bool,
+			) bool {
+	if cb == nil || c.cbForQueueHighWater != nil {
+		return false
+	}
+	c.cbForQueueHighWater = cb
+	return true
+}
+
+//set codec registry for value api
+func (c *Client) SetCodecs(codecs iface.ICodecRegistry) bool {
+	if codecs == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.codecs = codecs
+	return true
+}
+
+//get codec registry for value api
+func (c *Client) GetCodecs() iface.ICodecRegistry {
+	return c.getCodecs()
+}
+
+//add stream interceptor
+//run for outbound stream data and inbound stream data from gate server,
+//check `ReqMeta.Direction` for direction
+func (c *Client) AddStreamInterceptor(interceptor iface.StreamInterceptor) bool {
+	return c.interceptor.AddStream(interceptor)
+}
+
+//add outbound general request interceptor
+func (c *Client) AddGenInterceptor(interceptor iface.GenInterceptor) bool {
+	return c.interceptor.AddGen(interceptor)
+}
+
+//set default request timeout of service kind
+//used for request without deadline
+func (c *Client) SetTimeout(serviceKind string, timeout time.Duration) bool {
+	if serviceKind == "" || timeout <= 0 {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.timeoutMap[serviceKind] = timeout
+	for _, gate := range c.gateMap {
+		if gate.GetKind() == serviceKind {
+			gate.SetTimeout(timeout)
+		}
+	}
+	return true
+}
+
+//pick one gate server by service kind and routing key
+//if no routing rule for kind, same as `PickOneGateServer`
+func (c *Client) PickOneGateServerByKey(serviceKind, key string) iface.IGate {
+	gate := c.getGateByKey(serviceKind, key)
+	if gate != nil {
+		return gate
+	}
+	return c.PickOneGateServer(serviceKind)
+}
+
 //pick one rand gate server by service kind
 func (c *Client) PickOneGateServer(serviceKind string) iface.IGate {
 	//basic check
@@ -116,13 +490,14 @@ func (c *Client) PickOneGateServer(serviceKind string) iface.IGate {
 		return nil
 	}
 
-	//begin loop gate server map and pick one
-	for _, gs := range c.gateMap {
-		if gs.GetKind() == serviceKind {
-			return gs
-		}
+	//pick by balancer
+	gate := c.getGateByKey(serviceKind, "")
+	if gate != nil {
+		return gate
 	}
-	return nil
+
+	//begin loop gate server map and pick one
+	return c.getGateByKind(serviceKind)
 }
 
 //add gate server
@@ -132,6 +507,17 @@ func (c *Client) AddGateServer(
 					port int,
 					tags ... string,
 				) bool {
+	return c.AddGateServerWithTLS(serviceKind, host, port, nil, tags...)
+}
+
+//add gate server with tls option
+//if tls option is nil, use insecure mode
+func (c *Client) AddGateServerWithTLS(
+					serviceKind, host string,
+					port int,
+					tlsOption *define.TLSOption,
+					tags ... string,
+				) bool {
 	//basic check
 	if serviceKind == "" || host == "" || port <= 0 {
 		return false
@@ -148,42 +534,140 @@ func (c *Client) AddGateServer(
 
 	//init gate
 	gate := NewGate(serviceKind, host, port, tags...)
+	if tlsOption != nil {
+		loader, err := NewTLSLoader(tlsOption)
+		if err != nil {
+			log.Println("Client::AddGateServer, load tls failed, err:", err.Error())
+			gate.Quit()
+			return false
+		}
+		gate.SetTLSLoader(loader)
+	}
 
 	//set callback function
 	gate.SetCBForStreamReceived(c.cbForStreamReceived)
-	gate.SetCBForGateServerDown(c.cbForGateServerDown)
+	gate.SetCBForGateServerDown(c.gateServerDown)
 	gate.SetCBForGateServerUp(c.cbForGateServerUp)
+	gate.SetCBForStatusChanged(c.cbForGateStatus)
+	gate.SetCBForQueueHighWater(c.cbForQueueHighWater)
+	gate.SetCBForBind(c.bindOrUnbind)
 
 	//sync into map
 	c.Lock()
 	defer c.Unlock()
+	gate.SetHeartBeat(c.heartBeatRate, c.heartBeatMaxMiss)
+	gate.SetCBForAccessAuth(c.cbForAccessAuth)
+	gate.SetCBForAsyncResp(c.cbForAsyncResp)
+	gate.SetCBForServiceReq(c.cbForServiceReq)
+	gate.SetInterceptor(c.interceptor)
+	if timeout, ok := c.timeoutMap[serviceKind]; ok {
+		gate.SetTimeout(timeout)
+	}
+	if c.queueOption != nil {
+		gate.SetQueueOption(c.queueOption)
+	}
 	c.gateMap[address] = gate
 
+	//add into hash ring of kind
+	ring, ok := c.ringMap[serviceKind]
+	if !ok {
+		ring = NewHashRing(define.HashRingReplicas)
+		c.ringMap[serviceKind] = ring
+	}
+	ring.Add(address)
+
+	//connect gate server after setup
+	go gate.Connect(false)
+
 	return true
 }
 
+//remove gate server
+func (c *Client) RemoveGateServer(address string) bool {
+	//get gate
+	gate := c.getGateByAddr(address)
+	if gate == nil {
+		return false
+	}
+
+	//remove from map and hash ring
+	c.Lock()
+	delete(c.gateMap, address)
+	ring, ok := c.ringMap[gate.GetKind()]
+	if ok {
+		ring.Remove(address)
+	}
+	c.Unlock()
+
+	//quit gate
+	gate.Quit()
+	return true
+}
+
+//send general request to remote gate server by routing key
+//if no routing rule for service kind, same as `SendGenReq`
+func (c *Client) SendGenReqByKey(in *pb.GateReq, key string) *pb.GateResp {
+	resp, _ := c.SendGenReqByKeyCtx(context.Background(), in, key)
+	return resp
+}
+
 //send general request to remote gate server
 func (c *Client) SendGenReq(in *pb.GateReq) *pb.GateResp {
-	var (
-		gate iface.IGate
-	)
+	resp, _ := c.SendGenReqCtx(context.Background(), in)
+	return resp
+}
 
+//send general request to remote gate server by routing key with context
+//if context without deadline, use default timeout of service kind
+func (c *Client) SendGenReqByKeyCtx(
+				ctx context.Context,
+				in *pb.GateReq,
+				key string,
+			) (*pb.GateResp, error) {
 	//basic check
 	if in == nil {
-		return nil
-	}
-	if in.Address != "" {
-		//get gate by address
-		gate = c.getGateByAddr(in.Address)
-	}else{
-		//pick gate by service kind
-		gate = c.getGateByKind(in.Service)
+		return nil, define.ErrInvalidParameter
 	}
+
+	//pick gate
+	gate := c.getGateForGenReq(in, key)
 	if gate == nil {
-		return nil
+		return nil, define.ErrNoGate
 	}
+
 	//send general request
-	return gate.SendGenReq(in)
+	return gate.SendGenReqCtx(ctx, in)
+}
+
+//send general request to remote gate server with context
+//if context without deadline, use default timeout of service kind
+func (c *Client) SendGenReqCtx(
+				ctx context.Context,
+				in *pb.GateReq,
+			) (*pb.GateResp, error) {
+	return c.SendGenReqByKeyCtx(ctx, in, "")
+}
+
+//send async general request to remote gate server
+//response will be passed to cb, or cb for async response if cb is nil
+//return request id, zero means failed
+func (c *Client) SendAsyncGenReq(
+				in *pb.GateReq,
+				cb func(resp *pb.GateResp),
+			) uint64 {
+	//basic check
+	if in == nil {
+		return 0
+	}
+
+	//pick gate
+	gate := c.getGateForGenReq(in, "")
+	if gate == nil {
+		return 0
+	}
+
+	//send async general request
+	return gate.SendAsyncGenReq(in, cb)
 }
 
 //cast data to gate server
@@ -204,7 +688,150 @@ func (c *Client) CastData(
 	return bRet
 }
 
+//cast data to gate server with context
+//wait for queue room until context done
+func (c *Client) CastDataCtx(
+			ctx context.Context,
+			address string,
+			in *pb.ByteMessage,
+		) error {
+	//get remote gate by address
+	gate := c.getGateByAddr(address)
+	if gate == nil {
+		return define.ErrNoGate
+	}
+	return gate.CastDataCtx(ctx, in)
+}
+
+//cast data to one kind gates with context
+//if bound, routing rule or balancer set, only cast to one gate,
+//otherwise cast to all gates of kind and return the first error
+func (c *Client) CastDataByKindCtx(
+			ctx context.Context,
+			kind string,
+			in *pb.ByteMessage,
+		) error {
+	var (
+		err error
+		found bool
+	)
+
+	//basic check
+	if kind == "" || in == nil {
+		return define.ErrInvalidParameter
+	}
+	if in.Service == "" {
+		//set kind on a copy, keep caller's message unchanged
+		in = cloneByteMessage(in)
+		in.Service = kind
+	}
+
+	//cast to one gate by bind or routing rule
+	gate := c.getGateForData(kind, in)
+	if gate != nil {
+		return gate.CastDataCtx(ctx, in)
+	}
+
+	//loop gate and cast
+	for _, gate := range c.getAllGates() {
+		if gate.GetKind() != kind {
+			continue
+		}
+		found = true
+		subErr := gate.CastDataCtx(ctx, in)
+		if subErr != nil && err == nil {
+			err = subErr
+		}
+	}
+	if !found {
+		return define.ErrNoGate
+	}
+	return err
+}
+
+//send general request with value encoded by codec registry
+//response data decoded into resp if resp is not nil,
+//response error code returned as `define.RespError`
+func (c *Client) SendGenValue(
+				ctx context.Context,
+				kind string,
+				messageId uint32,
+				req, resp interface{},
+			) error {
+	//get codec registry
+	codecs := c.getCodecs()
+	if codecs == nil {
+		return &define.CodecError{
+			Op:define.CodecOpOfEncode,
+			MessageId:messageId,
+			Err:define.ErrCodecNotFound,
+		}
+	}
+
+	//encode request
+	data, err := codecs.Encode(messageId, req)
+	if err != nil {
+		return err
+	}
+
+	//send general request
+	gateResp, err := c.SendGenReqCtx(ctx, &pb.GateReq{
+		Service:kind,
+		MessageId:messageId,
+		Data:data,
+	})
+	if err != nil {
+		return err
+	}
+	if gateResp.ErrorCode != 0 {
+		return &define.RespError{
+			Code:gateResp.ErrorCode,
+			Message:gateResp.ErrorMessage,
+		}
+	}
+
+	//decode response
+	if resp == nil || len(gateResp.Data) <= 0 {
+		return nil
+	}
+	return codecs.DecodeInto(messageId, gateResp.Data, resp)
+}
+
+//cast value encoded by codec registry to one kind gates
+func (c *Client) CastValue(
+				ctx context.Context,
+				kind string,
+				messageId uint32,
+				v interface{},
+				connIds ...uint32,
+			) error {
+	//get codec registry
+	codecs := c.getCodecs()
+	if codecs == nil {
+		return &define.CodecError{
+			Op:define.CodecOpOfEncode,
+			MessageId:messageId,
+			Err:define.ErrCodecNotFound,
+		}
+	}
+
+	//encode value
+	data, err := codecs.Encode(messageId, v)
+	if err != nil {
+		return err
+	}
+
+	//cast data
+	return c.CastDataByKindCtx(ctx, kind, &pb.ByteMessage{
+		Service:kind,
+		MessageId:messageId,
+		Data:data,
+		ConnIds:connIds,
+	})
+}
+
 //cast data to one kind gates
+//if bound, routing rule or balancer set, only cast to one gate
 func (c *Client) CastDataByKind(kind string, in *pb.ByteMessage) bool {
 	if kind == "" || in == nil {
 		return false
@@ -213,9 +840,20 @@ func (c *Client) CastDataByKind(kind string, in *pb.ByteMessage) bool {
 	if c.gateMap == nil || len(c.gateMap) <= 0 {
 		return false
 	}
+	if in.Service == "" {
+		//set kind on a copy, keep caller's message unchanged
+		in = cloneByteMessage(in)
+		in.Service = kind
+	}
+
+	//cast to one gate by bind or routing rule
+	gate := c.getGateForData(kind, in)
+	if gate != nil {
+		return gate.CastData(in)
+	}
 
 	//loop gate and cast
-	for _, gate := range c.gateMap {
+	for _, gate := range c.getAllGates() {
 		if gate.GetKind() != kind {
 			continue
 		}
@@ -225,13 +863,61 @@ func (c *Client) CastDataByKind(kind string, in *pb.ByteMessage) bool {
 	return true
 }
 
+//call one kind gate with stream mode, wait for reply
+//gate picked by address, bind, routing rule or balancer
+func (c *Client) Call(
+				ctx context.Context,
+				kind string,
+				in *pb.ByteMessage,
+			) (*pb.ByteMessage, error) {
+	var (
+		gate iface.IGate
+	)
+
+	//basic check
+	if kind == "" || in == nil {
+		return nil, define.ErrInvalidParameter
+	}
+
+	//pick gate
+	if in.Address != "" {
+		gate = c.getGateByAddr(in.Address)
+	}else{
+		gate = c.getGateForData(kind, in)
+		if gate == nil {
+			gate = c.getGateByKind(kind)
+		}
+	}
+	if gate == nil {
+		return nil, define.ErrNoGate
+	}
+
+	//call gate with a copy, keep caller's request unchanged
+	req := cloneByteMessage(in)
+	req.Service = kind
+	return gate.Call(ctx, req)
+}
+
+//cast data to one kind gate by routing key
+//if no routing rule for kind, same as `CastDataByKind`
+func (c *Client) CastDataByKey(kind, key string, in *pb.ByteMessage) bool {
+	if kind == "" || in == nil {
+		return false
+	}
+	gate := c.getGateByKey(kind, key)
+	if gate != nil {
+		return gate.CastData(in)
+	}
+	return c.CastDataByKind(kind, in)
+}
+
 //cast data to all gate
 func (c *Client) CastDataToAll(in *pb.ByteMessage) bool {
 	if in == nil || c.gateMap == nil {
 		return false
 	}
 	//loop gate and cast
-	for _, gate := range c.gateMap {
+	for _, gate := range c.getAllGates() {
 		gate.CastData(in)
 	}
 	return true
@@ -272,11 +958,41 @@ func (c *Client) runMainProcess() {
 	}
 }
 
+//get all gates snapshot
+func (c *Client) getAllGates() []iface.IGate {
+	c.RLock()
+	defer c.RUnlock()
+	result := make([]iface.IGate, 0, len(c.gateMap))
+	for _, gate := range c.gateMap {
+		result = append(result, gate)
+	}
+	return result
+}
+
+//remove all gates from map and hash ring
+//return removed gates
+func (c *Client) removeAllGates() []iface.IGate {
+	c.Lock()
+	defer c.Unlock()
+	result := make([]iface.IGate, 0, len(c.gateMap))
+	for address, gate := range c.gateMap {
+		result = append(result, gate)
+		ring, ok := c.ringMap[gate.GetKind()]
+		if ok {
+			ring.Remove(address)
+		}
+	}
+	c.gateMap = make(map[string]iface.IGate)
+	return result
+}
+
 //get gate by address
 func (c *Client) getGateByAddr(address string) iface.IGate {
 	if address == "" {
 		return nil
 	}
+	c.RLock()
+	defer c.RUnlock()
 	v, ok := c.gateMap[address]
 	if !ok {
 		return nil
@@ -284,6 +1000,267 @@ func (c *Client) getGateByAddr(address string) iface.IGate {
 	return v
 }
 
+//get gate by kind and node tag or address
+func (c *Client) getGateByNode(kind, node string) iface.IGate {
+	if kind == "" || node == "" {
+		return nil
+	}
+	c.RLock()
+	defer c.RUnlock()
+	for addr, v := range c.gateMap {
+		if v.GetKind() != kind {
+			continue
+		}
+		if addr == node {
+			return v
+		}
+		for _, tag := range v.GetTags() {
+			if tag == node {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+//player bind or unbind from gate server
+//if bind without nodes, pin to the gate which send it
+func (c *Client) bindOrUnbind(from string, in *json.BindJson) bool {
+	if in.Nodes == nil {
+		in.Nodes = make(map[string]string)
+	}
+	if in.Opt == define.NodeOptBind && len(in.Nodes) <= 0 {
+		gate := c.getGateByAddr(from)
+		if gate == nil {
+			return false
+		}
+		in.Nodes[gate.GetKind()] = from
+	}
+
+	//unbind, remove sticky keys
+	if in.Opt == define.NodeOptUnbind {
+		playerId := in.PlayerId
+		old := c.bind.GetByConn(in.ConnId)
+		if playerId <= 0 && old != nil {
+			playerId = old.PlayerId
+		}
+		c.removeStickyKeys(in.ConnId, playerId, in.Nodes)
+	}
+	return c.bind.BindOrUnbind(in)
+}
+
+//remove sticky keys of connect and player
+//if kinds is empty, remove for all kinds
+func (c *Client) removeStickyKeys(
+				connId uint32,
+				playerId int64,
+				kinds map[string]string,
+			) {
+	keys := []string{fmt.Sprintf("conn:%d", connId)}
+	if playerId > 0 {
+		keys = append(keys, fmt.Sprintf("player:%d", playerId))
+	}
+	sticky := c.GetStickyTable()
+	for _, key := range keys {
+		if len(kinds) <= 0 {
+			sticky.RemoveKey(key)
+			continue
+		}
+		for kind := range kinds {
+			sticky.Remove(kind, key)
+		}
+	}
+}
+
+//front end connect closed
+//notify bound sub services, or all if not bound
+func (c *Client) clientClosed(connId uint32) bool {
+	//init byte message
+	in := &pb.ByteMessage{
+		MessageId:define.MessageIdOfClientClosed,
+		Data:[]byte{},
+		ConnIds:[]uint32{connId},
+	}
+
+	//get bind and clean up
+	bind := c.bind.GetByConn(connId)
+	c.bind.RemoveByConn(connId)
+	c.removeStickyKeys(connId, 0, nil)
+	if bind == nil || len(bind.Nodes) <= 0 {
+		return c.CastDataToAll(in)
+	}
+
+	//cast to bound gates
+	for kind, node := range bind.Nodes {
+		gate := c.getGateByNode(kind, node)
+		if gate == nil {
+			continue
+		}
+		gate.CastData(in)
+	}
+	return true
+}
+
+//get routing key of byte message
+//bound player id first, then connect id
+func (c *Client) getRouteKey(in *pb.ByteMessage) string {
+	if len(in.ConnIds) <= 0 {
+		return ""
+	}
+	connId := in.ConnIds[0]
+	bind := c.bind.GetByConn(connId)
+	if bind != nil && bind.PlayerId > 0 {
+		return fmt.Sprintf("player:%d", bind.PlayerId)
+	}
+	return fmt.Sprintf("conn:%d", connId)
+}
+
+//get codec registry
+func (c *Client) getCodecs() iface.ICodecRegistry {
+	c.RLock()
+	defer c.RUnlock()
+	return c.codecs
+}
+
+//get gate for general request
+//pick by address, routing key, balancer or service kind
+func (c *Client) getGateForGenReq(in *pb.GateReq, key string) iface.IGate {
+	//get gate by address
+	if in.Address != "" {
+		return c.getGateByAddr(in.Address)
+	}
+
+	//pick gate by routing key, balancer or service kind
+	gate := c.getGateByKey(in.Service, key)
+	if gate == nil {
+		gate = c.getGateByKind(in.Service)
+	}
+	return gate
+}
+
+//get gate for stream data by bind or routing rule
+//return nil if not bound and no routing rule and balancer for kind
+func (c *Client) getGateForData(kind string, in *pb.ByteMessage) iface.IGate {
+	//get bound gate if connect has bound
+	if len(in.ConnIds) == 1 {
+		node := c.bind.GetNode(in.ConnIds[0], kind)
+		gate := c.getGateByNode(kind, node)
+		if gate != nil {
+			return gate
+		}
+	}
+
+	//get gate by routing rule
+	return c.getGateByKey(kind, c.getRouteKey(in))
+}
+
+//get gate by kind and routing key pass routing rule or balancer
+//return nil if no routing rule and balancer for kind
+func (c *Client) getGateByKey(kind, key string) iface.IGate {
+	//basic check
+	if kind == "" {
+		return nil
+	}
+
+	//get rule, hash ring and balancer
+	c.RLock()
+	rule, hasRule := c.ruleMap[kind]
+	ring := c.ringMap[kind]
+	balancer := c.balancerMap[kind]
+	c.RUnlock()
+	if !hasRule || ring == nil || key == "" {
+		if balancer == nil {
+			return nil
+		}
+		return balancer.Pick(key, c.getActiveGatesByKind(kind))
+	}
+
+	//pick gate by rule
+	switch rule {
+	case define.NodeRuleOfHash:
+		{
+			//pick active gate from hash ring
+			return c.getGateFromRing(ring, key, "")
+		}
+	case define.NodeRuleOfPersistent:
+		{
+			//pick assigned gate first, even if in maintain
+			sticky := c.GetStickyTable()
+			gate := c.getGateByAddr(sticky.Get(kind, key))
+			if gate != nil && gate.IsActive() {
+				return gate
+			}
+			//assign new gate from hash ring
+			gate = c.getGateFromRing(ring, key, "")
+			if gate != nil {
+				sticky.Set(kind, key, gate.GetAddress())
+			}
+			return gate
+		}
+	}
+	return nil
+}
+
+//get available gates of kind, sorted by address
+//maintain gates are skipped for new work
+func (c *Client) getActiveGatesByKind(kind string) []iface.IGate {
+	result := make([]iface.IGate, 0)
+	for _, gate := range c.getAllGates() {
+		if gate.GetKind() == kind && gate.IsAvailable() {
+			result = append(result, gate)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetAddress() < result[j].GetAddress()
+	})
+	return result
+}
+
+//get available gate from hash ring, skip the excluded address
+//maintain gates are skipped for new keys
+func (c *Client) getGateFromRing(ring *HashRing, key, exclude string) iface.IGate {
+	address := ring.Get(key, func(node string) bool {
+		if node == exclude {
+			return false
+		}
+		gate := c.getGateByAddr(node)
+		return gate != nil && gate.IsAvailable()
+	})
+	return c.getGateByAddr(address)
+}
+
+//gate server down
+//reassign sticky keys of downed gate, then notify outside
+func (c *Client) gateServerDown(kind, address string) bool {
+	//reassign sticky keys for persistent rule
+	c.RLock()
+	rule, hasRule := c.ruleMap[kind]
+	ring := c.ringMap[kind]
+	sticky := c.sticky
+	c.RUnlock()
+	if hasRule && rule == define.NodeRuleOfPersistent && ring != nil {
+		moved := make(map[string]string)
+		for _, key := range sticky.GetKeys(kind, address) {
+			gate := c.getGateFromRing(ring, key, address)
+			if gate == nil {
+				sticky.Remove(kind, key)
+				continue
+			}
+			sticky.Set(kind, key, gate.GetAddress())
+			moved[key] = gate.GetAddress()
+		}
+		if len(moved) > 0 && c.cbForKeysMoved != nil {
+			c.cbForKeysMoved(kind, address, moved)
+		}
+	}
+
+	//notify outside
+	if c.cbForGateServerDown != nil {
+		c.cbForGateServerDown(kind, address)
+	}
+	return true
+}
+
 //pick rand gate by kind
 func (c *Client) getGateByKind(kind string) iface.IGate {
 	var (
@@ -295,13 +1272,20 @@ func (c *Client) getGateByKind(kind string) iface.IGate {
 		return nil
 	}
 
-	//pick address by kind
+	//pick address by kind, prefer available gate
+	c.RLock()
 	for addr, v := range c.gateMap {
-		if v.GetKind() == kind {
+		if v.GetKind() != kind {
+			continue
+		}
+		if address == "" || v.IsAvailable() {
 			address = addr
+		}
+		if v.IsAvailable() {
 			break
 		}
 	}
+	c.RUnlock()
 
 	if address == "" {
 		return nil
@@ -320,7 +1304,7 @@ func (c *Client) checkGateStatus() bool {
 	}
 
 	//loop check
-	for _, gate := range c.gateMap {
+	for _, gate := range c.getAllGates() {
 		if gate.ConnIsNil() {
 			gate.Connect(true)
 			continue
@@ -0,0 +1,232 @@
+package face
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/iface"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"reflect"
+	"sync"
+)
+
+/*
+ * codec face, implement of ICodec and ICodecRegistry
+ * - protobuf, json and msgpack codec
+ * - registry bind message id with go type and codec
+ * - codec failed return `define.CodecError`
+ */
+
+//protobuf codec
+type ProtoCodec struct {
+}
+
+//json codec
+type JsonCodec struct {
+}
+
+//msgpack codec
+type MsgpackCodec struct {
+}
+
+//registered codec info
+type codecInfo struct {
+	typ reflect.Type //value type, not pointer
+	codec iface.ICodec
+}
+
+//codec registry
+type CodecRegistry struct {
+	codecMap map[uint32]*codecInfo //messageId -> codecInfo
+	sync.RWMutex
+}
+
+/////////////////////////////////
+//construct for codecs
+/////////////////////////////////
+
+func NewProtoCodec() *ProtoCodec {
+	this := &ProtoCodec{}
+	return this
+}
+
+func NewJsonCodec() *JsonCodec {
+	this := &JsonCodec{}
+	return this
+}
+
+func NewMsgpackCodec() *MsgpackCodec {
+	this := &MsgpackCodec{}
+	return this
+}
+
+//construct codec registry
+func NewCodecRegistry() *CodecRegistry {
+	this := &CodecRegistry{
+		codecMap:make(map[uint32]*codecInfo),
+	}
+	return this
+}
+
+//////////////////////
+//implement of ICodec
+//////////////////////
+
+func (f *ProtoCodec) Name() string {
+	return define.CodecOfProto
+}
+
+func (f *ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, errors.New("value is not proto message")
+	}
+	return proto.Marshal(msg)
+}
+
+func (f *ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return errors.New("value is not proto message")
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func (f *JsonCodec) Name() string {
+	return define.CodecOfJson
+}
+
+func (f *JsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (f *JsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (f *MsgpackCodec) Name() string {
+	return define.CodecOfMsgpack
+}
+
+func (f *MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (f *MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+//////////////////////
+//implement of ICodecRegistry
+//////////////////////
+
+//register message id with value type and codec
+//sample is value or pointer of the type, like `&pb.GateReq{}`
+func (f *CodecRegistry) Register(
+				messageId uint32,
+				sample interface{},
+				codec iface.ICodec,
+			) error {
+	//basic check
+	if sample == nil || codec == nil {
+		return define.ErrInvalidParameter
+	}
+
+	//get value type
+	typ := reflect.TypeOf(sample)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	//sync into map
+	f.Lock()
+	defer f.Unlock()
+	f.codecMap[messageId] = &codecInfo{
+		typ:typ,
+		codec:codec,
+	}
+	return nil
+}
+
+//unregister message id
+func (f *CodecRegistry) Unregister(messageId uint32) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.codecMap, messageId)
+}
+
+//get codec by message id
+func (f *CodecRegistry) GetCodec(messageId uint32) iface.ICodec {
+	info := f.getInfo(messageId)
+	if info == nil {
+		return nil
+	}
+	return info.codec
+}
+
+//encode value by codec of message id
+func (f *CodecRegistry) Encode(messageId uint32, v interface{}) ([]byte, error) {
+	info := f.getInfo(messageId)
+	if info == nil {
+		return nil, f.genError(define.CodecOpOfEncode, messageId, "", define.ErrCodecNotFound)
+	}
+	data, err := info.codec.Marshal(v)
+	if err != nil {
+		return nil, f.genError(define.CodecOpOfEncode, messageId, info.codec.Name(), err)
+	}
+	return data, nil
+}
+
+//decode data into new value of registered type
+//return pointer of the type
+func (f *CodecRegistry) Decode(messageId uint32, data []byte) (interface{}, error) {
+	info := f.getInfo(messageId)
+	if info == nil {
+		return nil, f.genError(define.CodecOpOfDecode, messageId, "", define.ErrCodecNotFound)
+	}
+	v := reflect.New(info.typ).Interface()
+	err := info.codec.Unmarshal(data, v)
+	if err != nil {
+		return nil, f.genError(define.CodecOpOfDecode, messageId, info.codec.Name(), err)
+	}
+	return v, nil
+}
+
+//decode data into given value by codec of message id
+func (f *CodecRegistry) DecodeInto(messageId uint32, data []byte, v interface{}) error {
+	info := f.getInfo(messageId)
+	if info == nil {
+		return f.genError(define.CodecOpOfDecode, messageId, "", define.ErrCodecNotFound)
+	}
+	err := info.codec.Unmarshal(data, v)
+	if err != nil {
+		return f.genError(define.CodecOpOfDecode, messageId, info.codec.Name(), err)
+	}
+	return nil
+}
+
+////////////////
+//private func
+////////////////
+
+//get registered codec info
+func (f *CodecRegistry) getInfo(messageId uint32) *codecInfo {
+	f.RLock()
+	defer f.RUnlock()
+	info, ok := f.codecMap[messageId]
+	if !ok {
+		return nil
+	}
+	return info
+}
+
+//gen codec error
+func (f *CodecRegistry) genError(op string, messageId uint32, codec string, err error) error {
+	return &define.CodecError{
+		Op:op,
+		MessageId:messageId,
+		Codec:codec,
+		Err:err,
+	}
+}
@@ -0,0 +1,133 @@
+package face
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/iface"
+	pb "github.com/andyzhou/tinygate/proto"
+)
+
+//message of msgpack and json codec
+type testUser struct {
+	Id int64 `json:"id" msgpack:"id"`
+	Name string `json:"name" msgpack:"name"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	cases := []struct {
+		codec iface.ICodec
+		name string
+	}{
+		{NewJsonCodec(), define.CodecOfJson},
+		{NewMsgpackCodec(), define.CodecOfMsgpack},
+	}
+	for _, c := range cases {
+		if c.codec.Name() != c.name {
+			t.Fatalf("codec name = %s, want %s", c.codec.Name(), c.name)
+		}
+		data, err := c.codec.Marshal(&testUser{Id:1, Name:"tom"})
+		if err != nil {
+			t.Fatalf("%s marshal failed, err:%v", c.name, err)
+		}
+		user := &testUser{}
+		if err = c.codec.Unmarshal(data, user); err != nil {
+			t.Fatalf("%s unmarshal failed, err:%v", c.name, err)
+		}
+		if user.Id != 1 || user.Name != "tom" {
+			t.Fatalf("%s round trip got %+v", c.name, user)
+		}
+	}
+}
+
+func TestProtoCodec(t *testing.T) {
+	codec := NewProtoCodec()
+	data, err := codec.Marshal(&pb.GateReq{Service:"chat", MessageId:1})
+	if err != nil {
+		t.Fatalf("marshal failed, err:%v", err)
+	}
+	req := &pb.GateReq{}
+	if err = codec.Unmarshal(data, req); err != nil {
+		t.Fatalf("unmarshal failed, err:%v", err)
+	}
+	if req.Service != "chat" || req.MessageId != 1 {
+		t.Fatalf("round trip got %v", req)
+	}
+
+	//not proto message
+	if _, err = codec.Marshal(&testUser{}); err == nil {
+		t.Fatal("marshal non proto value should fail")
+	}
+	if err = codec.Unmarshal(data, &testUser{}); err == nil {
+		t.Fatal("unmarshal into non proto value should fail")
+	}
+}
+
+func TestCodecRegistry(t *testing.T) {
+	registry := NewCodecRegistry()
+	if registry.Register(1, nil, NewJsonCodec()) == nil ||
+		registry.Register(1, &testUser{}, nil) == nil {
+		t.Fatal("register with nil para should fail")
+	}
+
+	//value or pointer sample both decode into pointer
+	registry.Register(1, &testUser{}, NewJsonCodec())
+	registry.Register(2, testUser{}, NewMsgpackCodec())
+	for _, messageId := range []uint32{1, 2} {
+		data, err := registry.Encode(messageId, &testUser{Id:int64(messageId), Name:"tom"})
+		if err != nil {
+			t.Fatalf("encode %d failed, err:%v", messageId, err)
+		}
+		v, err := registry.Decode(messageId, data)
+		if err != nil {
+			t.Fatalf("decode %d failed, err:%v", messageId, err)
+		}
+		user, ok := v.(*testUser)
+		if !ok || user.Id != int64(messageId) {
+			t.Fatalf("decode %d got %#v", messageId, v)
+		}
+
+		//decode into given value
+		into := &testUser{}
+		if err = registry.DecodeInto(messageId, data, into); err != nil || into.Name != "tom" {
+			t.Fatalf("decode into %d got %+v, err:%v", messageId, into, err)
+		}
+	}
+	if registry.GetCodec(2).Name() != define.CodecOfMsgpack {
+		t.Fatal("codec of message 2 should be msgpack")
+	}
+
+	//unregistered
+	registry.Unregister(2)
+	if registry.GetCodec(2) != nil {
+		t.Fatal("codec of message 2 should be removed")
+	}
+	_, err := registry.Decode(2, nil)
+	if !errors.Is(err, define.ErrCodecNotFound) {
+		t.Fatalf("decode unregistered got %v, want ErrCodecNotFound", err)
+	}
+}
+
+func TestCodecRegistryError(t *testing.T) {
+	registry := NewCodecRegistry()
+	registry.Register(1, &testUser{}, NewJsonCodec())
+
+	//codec error carry op, message id and codec name
+	_, err := registry.Decode(1, []byte("{broken"))
+	var codecErr *define.CodecError
+	if !errors.As(err, &codecErr) {
+		t.Fatalf("decode broken data got %v, want CodecError", err)
+	}
+	if codecErr.Op != define.CodecOpOfDecode || codecErr.MessageId != 1 ||
+		codecErr.Codec != define.CodecOfJson || codecErr.Err == nil {
+		t.Fatalf("codec error mismatch, %+v", codecErr)
+	}
+
+	//encode without registry entry
+	_, err = registry.Encode(3, &testUser{})
+	if !errors.As(err, &codecErr) || codecErr.Op != define.CodecOpOfEncode ||
+		!errors.Is(err, define.ErrCodecNotFound) {
+		t.Fatalf("encode unregistered got %v", err)
+	}
+}
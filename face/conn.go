@@ -0,0 +1,151 @@
+package face
+
+import (
+	"errors"
+	"github.com/andyzhou/tinygate/iface"
+	pb "github.com/andyzhou/tinygate/proto"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+ * connect meta face, implement of IConnMeta
+ * - used at gate client side
+ * - one front end connect one meta instance
+ * - keep remote address, open time, bytes stat, auth state and attributes
+ */
+
+//face info
+type ConnMeta struct {
+	conn iface.IConn //front end connect
+	remoteAddr string
+	openTime time.Time
+	bytesIn uint64
+	bytesOut uint64
+	authed int32
+	attrMap map[string]interface{} //key -> value
+	sync.RWMutex
+}
+
+//construct
+func NewConnMeta(conn iface.IConn) *ConnMeta {
+	//self init
+	this := &ConnMeta{
+		conn:conn,
+		remoteAddr:conn.GetRemoteAddr(),
+		openTime:time.Now(),
+		attrMap:make(map[string]interface{}),
+	}
+	return this
+}
+
+//////////////////////
+//implement of IConnMeta
+//////////////////////
+
+//close front end connect
+func (f *ConnMeta) Close() {
+	f.conn.Close()
+}
+
+//write message to front end connect
+func (f *ConnMeta) Write(in *pb.ByteMessage) error {
+	if in == nil {
+		return errors.New("invalid parameter")
+	}
+	err := f.conn.Write(in)
+	if err != nil {
+		return err
+	}
+	f.AddBytesOut(len(in.Data))
+	return nil
+}
+
+//get front end connect
+func (f *ConnMeta) GetConn() iface.IConn {
+	return f.conn
+}
+
+//get connect id
+func (f *ConnMeta) GetConnId() uint32 {
+	return f.conn.GetConnId()
+}
+
+//get remote address
+func (f *ConnMeta) GetRemoteAddr() string {
+	return f.remoteAddr
+}
+
+//get open time
+func (f *ConnMeta) GetOpenTime() time.Time {
+	return f.openTime
+}
+
+//get bytes in
+func (f *ConnMeta) GetBytesIn() uint64 {
+	return atomic.LoadUint64(&f.bytesIn)
+}
+
+//get bytes out
+func (f *ConnMeta) GetBytesOut() uint64 {
+	return atomic.LoadUint64(&f.bytesOut)
+}
+
+//add bytes in
+func (f *ConnMeta) AddBytesIn(size int) {
+	if size <= 0 {
+		return
+	}
+	atomic.AddUint64(&f.bytesIn, uint64(size))
+}
+
+//add bytes out
+func (f *ConnMeta) AddBytesOut(size int) {
+	if size <= 0 {
+		return
+	}
+	atomic.AddUint64(&f.bytesOut, uint64(size))
+}
+
+//set auth state
+func (f *ConnMeta) SetAuthed(authed bool) {
+	var val int32
+	if authed {
+		val = 1
+	}
+	atomic.StoreInt32(&f.authed, val)
+}
+
+//check auth state
+func (f *ConnMeta) IsAuthed() bool {
+	return atomic.LoadInt32(&f.authed) == 1
+}
+
+//set attribute
+func (f *ConnMeta) SetAttr(key string, val interface{}) {
+	if key == "" {
+		return
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.attrMap[key] = val
+}
+
+//get attribute
+func (f *ConnMeta) GetAttr(key string) interface{} {
+	f.RLock()
+	defer f.RUnlock()
+	val, ok := f.attrMap[key]
+	if !ok {
+		return nil
+	}
+	return val
+}
+
+//remove attribute
+func (f *ConnMeta) DelAttr(key string) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.attrMap, key)
+}
@@ -2,14 +2,22 @@ package face
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/iface"
 	"github.com/andyzhou/tinygate/json"
 	pb "github.com/andyzhou/tinygate/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"io"
 	"log"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,6 +29,21 @@ import (
  * - tcp service will be client side
  */
 
+//async request info
+type asyncReq struct {
+	service string
+	messageId uint32
+	expire time.Time
+	cb func(resp *pb.GateResp)
+}
+
+//flush request of gate shutdown
+type gateFlush struct {
+	ctx context.Context
+	dropped int //dropped count of queued requests, used by main process
+	droppedChan chan int //notify dropped count when flush done
+}
+
 //gate info
 type Gate struct {
 	kind string //service kind
@@ -31,16 +54,43 @@ type Gate struct {
 	stream pb.GateService_BindStreamClient //stream client
 	ctx context.Context
 	reqChan chan pb.ByteMessage
+	queue *QueueGuard //overflow policy of request queue
+	flushChan chan *gateFlush //chan for flush queued requests before quit
+	recvChan chan struct{} //closed when receive process exit
 	closeChan chan bool
+	closing int32 //shutting down or not, reject new requests
 	needQuit bool
+	connecting bool //connect in progress, guarded by locker
+	heartBeatRate time.Duration //heart beat send rate
+	heartBeatMaxMiss int //max missed beats before gate down
+	heartBeatTicker *time.Ticker
+	lastActive int64 //last active time of gate server, unix nano seconds
+	rtt int64 //round trip time of heart beat, nano seconds
+	active int32 //stream active or not
+	status int32 //node status of gate server, `pb.NodeStatus`
+	tlsLoader *TLSLoader //tls loader, optional
+	weight int //weight for weighted balancer
+	inFlight int64 //in flight general requests
+	latency int64 //moving average latency of general request, nano seconds
+	timeout time.Duration //default timeout of request without deadline
+	interceptor iface.IInterceptor //interceptor chain, optional
+	reqId uint64 //last async request id
+	asyncMap map[uint64]*asyncReq //pending async requests, reqId -> asyncReq
+	callMap map[uint64]chan *pb.ByteMessage //pending stream calls, seq -> reply chan
 	sync.RWMutex
 	//cb func
 	cbForStreamReceived func(from string, in *pb.ByteMessage) bool //call back for received data
 	cbForGateServerDown func(kind, addr string) bool //call back for gate server down
 	cbForGateServerUp func(kind, addr string) bool //call back for gate server up
+	cbForBind func(from string, in *json.BindJson) bool //call back for player bind or unbind
+	cbForAccessAuth func(kind string) *pb.AccessAuth //call back for get access auth
+	cbForAsyncResp func(from string, reqId uint64, resp *pb.GateResp) bool //call back for async response
+	cbForServiceReq func(from string, in *pb.ByteMessage) *pb.ByteMessage //call back for sub service request
+	cbForStatusChanged func(kind, addr string, from, to pb.NodeStatus) bool //call back for node status changed
 }
 
 //construct
+//call `Connect` after cb setup
 func NewGate(
 			serviceKind,
 			serverHost string,
@@ -54,12 +104,18 @@ func NewGate(
 		address:fmt.Sprintf("%s:%d", serverHost, serverPort),
 		ctx:context.Background(),
 		reqChan:make(chan pb.ByteMessage, define.GateReqChanSize),
+		queue:NewQueueGuard(),
+		flushChan:make(chan *gateFlush, 1),
 		closeChan:make(chan bool, 1),
+		heartBeatRate:time.Second * define.HeartBeatRate,
+		heartBeatMaxMiss:define.HeartBeatMaxMiss,
+		heartBeatTicker:time.NewTicker(time.Second * define.HeartBeatRate),
+		weight:define.GateDefaultWeight,
+		timeout:time.Second * define.GateReqTimeout,
+		asyncMap:make(map[uint64]*asyncReq),
+		callMap:make(map[uint64]chan *pb.ByteMessage),
 	}
 
-	//inter init
-	this.interInit()
-
 	//spawn main process
 	go this.runMainProcess()
 
@@ -83,33 +139,143 @@ func (c *Gate) Quit() {
 	c.Lock()
 	defer c.Unlock()
 	c.needQuit = true
+	if c.tlsLoader != nil {
+		c.tlsLoader.Quit()
+	}
 	c.closeChan <- true
 }
 
+//shutdown gracefully
+//stop accepting new requests, flush queued requests over stream,
+//close send side of bind stream and wait for receive process exit.
+//return dropped count of queued requests if context done
+func (c *Gate) Shutdown(ctx context.Context) (int, error) {
+	var (
+		dropped int
+		err error
+	)
+
+	//basic check
+	if ctx == nil {
+		return 0, define.ErrInvalidParameter
+	}
+	if !atomic.CompareAndSwapInt32(&c.closing, 0, 1) {
+		return 0, define.ErrGateClosed
+	}
+
+	//stop reconnect
+	c.Lock()
+	c.needQuit = true
+	recvChan := c.recvChan
+	c.Unlock()
+
+	//flush queued requests in main process
+	flush := &gateFlush{
+		ctx:ctx,
+		droppedChan:make(chan int, 1),
+	}
+	select {
+	case c.flushChan <- flush:
+	case <- ctx.Done():
+		return len(c.reqChan), c.convertErr(ctx.Err())
+	}
+	select {
+	case dropped = <- flush.droppedChan:
+	case <- ctx.Done():
+		return len(c.reqChan), c.convertErr(ctx.Err())
+	}
+	if dropped > 0 {
+		if ctx.Err() != nil {
+			err = c.convertErr(ctx.Err())
+		}else{
+			err = define.ErrGateDown
+		}
+	}
+
+	//wait for receive process exit
+	if recvChan != nil {
+		select {
+		case <- recvChan:
+		case <- ctx.Done():
+			if err == nil {
+				err = c.convertErr(ctx.Err())
+			}
+		}
+	}
+
+	//release connect
+	c.Lock()
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	c.Unlock()
+	if c.tlsLoader != nil {
+		c.tlsLoader.Quit()
+	}
+	return dropped, err
+}
+
 //cast data to server with stream mode
-func (c *Gate) CastData(in *pb.ByteMessage) (bRet bool) {
+func (c *Gate) CastData(in *pb.ByteMessage) bool {
 	//basic check
 	if in == nil || in.MessageId < 0 || in.Data == nil {
-		return
+		return false
 	}
 
-	//try catch panic
-	defer func() {
-		if err := recover(); err != nil {
-			log.Println("Gate::CastData panic, err:", err)
-			bRet = false
-			return
-		}
-	}()
+	//send request pass interceptor chain
+	err := c.interceptStream(
+				define.DirectionOfOutbound,
+				in,
+				func(meta *define.ReqMeta, in *pb.ByteMessage) error {
+					return c.enqueue(in)
+				},
+			)
+	return err == nil
+}
+
+//cast data to server with stream mode, wait for queue room until context done
+//if context without deadline, use default timeout
+func (c *Gate) CastDataCtx(ctx context.Context, in *pb.ByteMessage) error {
+	//basic check
+	if ctx == nil || in == nil || in.Data == nil {
+		return define.ErrInvalidParameter
+	}
+
+	//send request pass interceptor chain
+	return c.interceptStream(
+				define.DirectionOfOutbound,
+				in,
+				func(meta *define.ReqMeta, in *pb.ByteMessage) error {
+					return c.enqueueCtx(ctx, in)
+				},
+			)
+}
+
+//check stream is active or not
+func (c *Gate) IsActive() bool {
+	return atomic.LoadInt32(&c.active) == 1
+}
 
-	//send request
-	c.reqChan <- *in
-	bRet = true
-	return
+//check gate can accept new work or not
+//maintain or down gate only serve existing bound or sticky keys
+func (c *Gate) IsAvailable() bool {
+	if !c.IsActive() {
+		return false
+	}
+	status := c.GetStatus()
+	return status == pb.NodeStatus_NODE_UP || status == pb.NodeStatus_NODE_ACTIVE
+}
+
+//get node status of gate server
+func (c *Gate) GetStatus() pb.NodeStatus {
+	return pb.NodeStatus(atomic.LoadInt32(&c.status))
 }
 
 //check connect is nil or not
 func (c *Gate) ConnIsNil() bool {
+	c.RLock()
+	defer c.RUnlock()
 	if c.conn == nil {
 		return true
 	}
@@ -126,6 +292,11 @@ func (c *Gate) GetKind() string {
 	return c.kind
 }
 
+//get remote server address
+func (c *Gate) GetAddress() string {
+	return c.address
+}
+
 //get tag
 func (c *Gate) GetTags() []string {
 	return c.tags
@@ -133,20 +304,271 @@ func (c *Gate) GetTags() []string {
 
 //get connect state
 func (c *Gate) GetConnStat()string {
-	return c.conn.GetState().String()
+	c.RLock()
+	conn := c.conn
+	c.RUnlock()
+	if conn == nil {
+		return ""
+	}
+	return conn.GetState().String()
+}
+
+//get round trip time of heart beat
+func (c *Gate) GetRTT() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.rtt))
+}
+
+//get in flight requests, include queued stream data
+func (c *Gate) GetInFlight() int64 {
+	return atomic.LoadInt64(&c.inFlight) + int64(len(c.reqChan))
+}
+
+//get moving average latency of general request
+func (c *Gate) GetLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.latency))
+}
+
+//get weight
+func (c *Gate) GetWeight() int {
+	c.RLock()
+	defer c.RUnlock()
+	return c.weight
+}
+
+//set weight for weighted balancer
+func (c *Gate) SetWeight(weight int) bool {
+	if weight < 0 {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.weight = weight
+	return true
+}
+
+//get last active time of gate server
+func (c *Gate) GetLastActive() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&c.lastActive))
+}
+
+//set heart beat option
+//gate will be down after `maxMiss` beats without any data from gate server,
+//rate should be less than `rate * maxMiss` of gate server,
+//or it will follow rate of gate server after node up.
+func (c *Gate) SetHeartBeat(rate time.Duration, maxMiss int) bool {
+	if rate <= 0 || maxMiss <= 0 {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.heartBeatRate = rate
+	c.heartBeatMaxMiss = maxMiss
+	c.heartBeatTicker.Reset(rate)
+	return true
+}
+
+//set default timeout of request without deadline
+func (c *Gate) SetTimeout(timeout time.Duration) bool {
+	if timeout <= 0 {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.timeout = timeout
+	return true
+}
+
+//get default timeout of request without deadline
+func (c *Gate) GetTimeout() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	return c.timeout
 }
 
 //send general request to gate server
-//this is sync request
+//this is sync request, return nil if failed
 func (c *Gate) SendGenReq(in *pb.GateReq) *pb.GateResp {
+	resp, _ := c.SendGenReqCtx(context.Background(), in)
+	return resp
+}
+
+//send general request to gate server with context
+//if context without deadline, use default timeout
+func (c *Gate) SendGenReqCtx(
+				ctx context.Context,
+				in *pb.GateReq,
+			) (*pb.GateResp, error) {
+	//basic check
+	if ctx == nil || in == nil {
+		return nil, define.ErrInvalidParameter
+	}
+	c.RLock()
+	client := c.client
+	c.RUnlock()
+	if client == nil || !c.IsActive() {
+		return nil, define.ErrGateDown
+	}
+
+	//fill access auth on copy, keep request of caller untouched
+	if in.Auth == nil {
+		in = cloneGenReq(in)
+		in.Auth = c.getAccessAuth()
+	}
+
+	//update stat
+	atomic.AddInt64(&c.inFlight, 1)
+	begin := time.Now()
+	defer func() {
+		atomic.AddInt64(&c.inFlight, -1)
+		c.updateLatency(time.Since(begin))
+	}()
+
+	//send request pass interceptor chain
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.interceptGen(
+				in,
+				func(meta *define.ReqMeta, in *pb.GateReq) (*pb.GateResp, error) {
+					resp, err := client.GenReq(ctx, in)
+					if err != nil {
+						return nil, c.convertErr(err)
+					}
+					return resp, nil
+				},
+			)
+}
+
+//send async general request to gate server
+//request will be acknowledged immediately,
+//response will be delivered pass bind stream later.
+//cb is optional, if nil, response will be passed to cb for async response.
+//return request id, zero means failed
+func (c *Gate) SendAsyncGenReq(
+				in *pb.GateReq,
+				cb func(resp *pb.GateResp),
+			) uint64 {
 	if in == nil {
-		return nil
+		return 0
+	}
+	c.RLock()
+	client := c.client
+	c.RUnlock()
+	if client == nil || !c.IsActive() {
+		return 0
+	}
+
+	//fill access auth and async flag on copy
+	in = cloneGenReq(in)
+	if in.Auth == nil {
+		in.Auth = c.getAccessAuth()
 	}
-	resp, err := c.client.GenReq(context.Background(), in)
+
+	//add into pending map before send, response may arrive before ack
+	reqId := atomic.AddUint64(&c.reqId, 1)
+	c.Lock()
+	c.asyncMap[reqId] = &asyncReq{
+		service:in.Service,
+		messageId:in.MessageId,
+		expire:time.Now().Add(time.Second * define.AsyncReqTimeout),
+		cb:cb,
+	}
+	c.Unlock()
+
+	//send with request id in metadata
+	//acknowledge should not wait longer than async timeout
+	in.IsAsync = true
+	ctx, cancel := context.WithTimeout(
+				context.Background(),
+				time.Second * define.AsyncReqTimeout,
+			)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(
+				ctx,
+				define.MetaKeyOfReqId, strconv.FormatUint(reqId, 10),
+			)
+	_, err := c.interceptGen(
+				in,
+				func(meta *define.ReqMeta, in *pb.GateReq) (*pb.GateResp, error) {
+					return client.GenReq(ctx, in)
+				},
+			)
 	if err != nil {
-		return nil
+		log.Println("Gate::SendAsyncGenReq failed, err:", err.Error())
+		c.Lock()
+		delete(c.asyncMap, reqId)
+		c.Unlock()
+		return 0
+	}
+	return reqId
+}
+
+//call gate server with stream mode, wait for reply
+//if context without deadline, use default timeout
+func (c *Gate) Call(
+				ctx context.Context,
+				in *pb.ByteMessage,
+			) (*pb.ByteMessage, error) {
+	//basic check
+	if ctx == nil || in == nil {
+		return nil, define.ErrInvalidParameter
+	}
+	if !c.IsActive() {
+		return nil, define.ErrGateDown
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	//add into pending map before send
+	seq := atomic.AddUint64(&c.reqId, 1)
+	replyChan := make(chan *pb.ByteMessage, 1)
+	c.Lock()
+	c.callMap[seq] = replyChan
+	c.Unlock()
+	defer func() {
+		c.Lock()
+		delete(c.callMap, seq)
+		c.Unlock()
+	}()
+
+	//send pass stream and interceptor chain
+	err := c.interceptStream(
+				define.DirectionOfOutbound,
+				in,
+				func(meta *define.ReqMeta, in *pb.ByteMessage) error {
+					//init call json
+					callJson := json.NewCallJson()
+					callJson.Seq = seq
+					callJson.MessageId = in.MessageId
+					callJson.Data = in.Data
+					if deadline, ok := ctx.Deadline(); ok {
+						callJson.Timeout = time.Until(deadline).Milliseconds()
+					}
+					req := &pb.ByteMessage{
+						Service:in.Service,
+						MessageId:define.MessageIdOfCallReq,
+						Data:callJson.Encode(),
+						Address:in.Address,
+						ConnIds:in.ConnIds,
+					}
+					return c.enqueueCtx(ctx, req)
+				},
+			)
+	if err != nil {
+		return nil, err
+	}
+
+	//wait for reply
+	select {
+	case resp, ok := <- replyChan:
+		if !ok {
+			return nil, define.ErrGateDown
+		}
+		return resp, nil
+	case <- ctx.Done():
+		//notify gate server to remove pending call
+		c.cancelCall(in.Service, seq)
+		return nil, c.convertErr(ctx.Err())
 	}
-	return resp
 }
 
 //set cb for receive data for server with stream mode
@@ -160,6 +582,39 @@ func (c *Gate) SetCBForStreamReceived(
 	return true
 }
 
+//set overflow policy of request queue
+func (c *Gate) SetQueueOption(option *define.QueueOption) bool {
+	return c.queue.SetOption(option)
+}
+
+//get stat of request queue
+func (c *Gate) GetQueueStat() define.QueueStat {
+	return c.queue.GetStat(c.reqChan)
+}
+
+//set cb for request queue crossing high water mark
+func (c *Gate) SetCBForQueueHighWater(
+			cb func(kind, addr string, depth int, over bool) bool,
+		) bool {
+	if cb == nil {
+		return false
+	}
+	return c.queue.SetCBForHighWater(func(depth int, over bool) {
+		cb(c.kind, c.address, depth, over)
+	})
+}
+
+//set cb for node status changed
+func (c *Gate) SetCBForStatusChanged(
+			cb func(kind, addr string, from, to pb.NodeStatus) bool,
+		) bool {
+	if cb == nil {
+		return false
+	}
+	c.cbForStatusChanged = cb
+	return true
+}
+
 //set cb for gate server down
 func (c *Gate) SetCBForGateServerDown(
 				cb func(string, string) bool,
@@ -182,6 +637,81 @@ func (c *Gate) SetCBForGateServerUp(
 	return true
 }
 
+//set cb for player bind or unbind from gate server
+func (c *Gate) SetCBForBind(
+				cb func(from string, in *json.BindJson) bool,
+			) bool {
+	if cb == nil || c.cbForBind != nil {
+		return false
+	}
+	c.cbForBind = cb
+	return true
+}
+
+//set cb for async response without request cb
+func (c *Gate) SetCBForAsyncResp(
+				cb func(from string, reqId uint64, resp *pb.GateResp) bool,
+			) bool {
+	if cb == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.cbForAsyncResp = cb
+	return true
+}
+
+//set cb for request from sub service
+//return value of cb will be replied to sub service
+func (c *Gate) SetCBForServiceReq(
+				cb func(from string, in *pb.ByteMessage) *pb.ByteMessage,
+			) bool {
+	if cb == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.cbForServiceReq = cb
+	return true
+}
+
+//set interceptor chain
+//outbound stream and general request, inbound stream data
+func (c *Gate) SetInterceptor(interceptor iface.IInterceptor) bool {
+	if interceptor == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.interceptor = interceptor
+	return true
+}
+
+//set tls loader, should be called before connect
+func (c *Gate) SetTLSLoader(loader *TLSLoader) bool {
+	if loader == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.tlsLoader = loader
+	return true
+}
+
+//set cb for get access auth
+//used for bind stream metadata and general request without auth
+func (c *Gate) SetCBForAccessAuth(
+				cb func(kind string) *pb.AccessAuth,
+			) bool {
+	if cb == nil {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.cbForAccessAuth = cb
+	return true
+}
+
 ///////////////
 //private func
 ///////////////
@@ -189,12 +719,13 @@ func (c *Gate) SetCBForGateServerUp(
 //cast data to gate server pass stream mode
 func (c *Gate) castData(in *pb.ByteMessage) bool {
 	//basic check
-	if in == nil || c.stream == nil {
+	stream := c.getStream()
+	if in == nil || stream == nil {
 		return false
 	}
 
 	//send data pass stream mode
-	err := c.stream.Send(in)
+	err := stream.Send(in)
 	if err != nil {
 		log.Println("Gate::castData failed, err:", err.Error())
 		//try reconnect
@@ -205,27 +736,37 @@ func (c *Gate) castData(in *pb.ByteMessage) bool {
 
 //receive stream data from gate server
 //if set cb, will call the cb for received stream data
-func (c *Gate) receiveGateStream() {
+func (c *Gate) receiveGateStream(recvChan chan struct{}) {
 	var (
 		in *pb.ByteMessage
 		err error
 	)
 
+	//notify receive process exit
+	defer close(recvChan)
+
 	//basic check
-	if c.stream == nil || c.cbForStreamReceived == nil {
+	stream := c.getStream()
+	if stream == nil {
 		return
 	}
 
 	//loop receive
 	for {
-		in, err = c.stream.Recv()
-		if err == io.EOF {
-			log.Println("Gate::receiveGateStream, gate data EOF")
-			continue
-		}
+		in, err = stream.Recv()
 		if err != nil {
-			log.Println("Gate::receiveGateStream, Receive gate data failed, " +
-						"err:", err.Error())
+			if err == io.EOF {
+				//stream closed by gate server, like draining
+				log.Println("Gate::receiveGateStream, gate data EOF")
+			}else{
+				log.Println("Gate::receiveGateStream, Receive gate data failed, " +
+							"err:", err.Error())
+			}
+			atomic.StoreInt32(&c.active, 0)
+			c.updateStatus(pb.NodeStatus_NODE_DOWN)
+			//response of pending async requests will be lost
+			c.cleanAsyncReq(true, "gate server down")
+			c.cleanCall()
 			//gate server down, call the relate cb func to notify client side
 			if c.cbForGateServerDown != nil {
 				c.cbForGateServerDown(c.kind, c.address)
@@ -233,23 +774,567 @@ func (c *Gate) receiveGateStream() {
 			break
 		}
 
-		//call cb for cast gate data to current service node
-		if c.cbForStreamReceived != nil {
-			c.cbForStreamReceived(c.address, in)
+		//any data means gate server active
+		atomic.StoreInt64(&c.lastActive, time.Now().UnixNano())
+
+		//do relate opt by message id
+		switch in.MessageId {
+		case define.MessageIdOfHeartBeat:
+			{
+				//heart beat echo from gate server
+				c.heartBeatReceived(in)
+			}
+		case define.MessageIdOfBindOrUnbind:
+			{
+				//player bind or unbind
+				c.bindOrUnbind(in)
+			}
+		case define.MessageIdOfAsyncResp:
+			{
+				//async general response
+				c.asyncRespReceived(in)
+			}
+		case define.MessageIdOfCallResp:
+			{
+				//stream call response
+				c.callRespReceived(in)
+			}
+		case define.MessageIdOfServiceReq:
+			{
+				//request from sub service, reply in new process
+				go c.serviceReqReceived(in)
+			}
+		case define.MessageIdOfNodeStatus:
+			{
+				//node status of sub service
+				c.statusReceived(in)
+			}
+		default:
+			{
+				//call cb for cast gate data to current service node
+				c.interceptStream(
+					define.DirectionOfInbound,
+					in,
+					func(meta *define.ReqMeta, in *pb.ByteMessage) error {
+						if c.cbForStreamReceived != nil {
+							c.cbForStreamReceived(c.address, in)
+						}
+						return nil
+					},
+				)
+			}
 		}
 	}
 
-	//lost connect, try reconnect
-	if !c.needQuit {
+	//lost connect, try reconnect after a while
+	c.RLock()
+	needQuit := c.needQuit
+	c.RUnlock()
+	if !needQuit {
+		time.Sleep(time.Second * define.GateReconnectRate)
 		go c.connect(true)
 	}
 }
 
+//send request into chan
+func (c *Gate) enqueue(in *pb.ByteMessage) (err error) {
+	//try catch panic, request chan closed
+	defer func() {
+		if subErr := recover(); subErr != nil {
+			log.Println("Gate::enqueue panic, err:", subErr)
+			err = define.ErrGateDown
+		}
+	}()
+
+	//check shutting down
+	if atomic.LoadInt32(&c.closing) == 1 {
+		return define.ErrGateClosed
+	}
+
+	//send request by overflow policy
+	return c.queue.Push(nil, c.reqChan, in)
+}
+
+//send request into chan, wait for queue room until context done
+func (c *Gate) enqueueCtx(ctx context.Context, in *pb.ByteMessage) (err error) {
+	//try catch panic, request chan closed
+	defer func() {
+		if subErr := recover(); subErr != nil {
+			log.Println("Gate::enqueueCtx panic, err:", subErr)
+			err = define.ErrGateDown
+		}
+	}()
+
+	//check shutting down
+	if atomic.LoadInt32(&c.closing) == 1 {
+		return define.ErrGateClosed
+	}
+
+	//send request by overflow policy
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.queue.Push(ctx, c.reqChan, in)
+}
+
+//notify gate server the call has been given up
+//without wait, pending call expired on server side if queue is full
+func (c *Gate) cancelCall(service string, seq uint64) {
+	//try catch panic, request chan closed
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Gate::cancelCall panic, err:", err)
+		}
+	}()
+
+	//init call json
+	callJson := json.NewCallJson()
+	callJson.Seq = seq
+
+	//send request without wait
+	select {
+	case c.reqChan <- pb.ByteMessage{
+			Service:service,
+			MessageId:define.MessageIdOfCallCancel,
+			Data:callJson.Encode(),
+		}:
+	default:
+	}
+}
+
+//run stream data pass interceptor chain
+//inter message skip the chain
+func (c *Gate) interceptStream(
+				direction int,
+				in *pb.ByteMessage,
+				final iface.StreamHandler,
+			) error {
+	meta := c.getReqMeta(direction, in.Service)
+	c.RLock()
+	interceptor := c.interceptor
+	c.RUnlock()
+	if interceptor == nil || in.MessageId <= define.MessageIdOfInterMax {
+		return final(meta, in)
+	}
+	return interceptor.InterceptStream(meta, in, final)
+}
+
+//run general request pass interceptor chain
+func (c *Gate) interceptGen(
+				in *pb.GateReq,
+				final iface.GenHandler,
+			) (*pb.GateResp, error) {
+	meta := c.getReqMeta(define.DirectionOfOutbound, in.Service)
+	c.RLock()
+	interceptor := c.interceptor
+	c.RUnlock()
+	if interceptor == nil {
+		return final(meta, in)
+	}
+	return interceptor.InterceptGen(meta, in, final)
+}
+
+//get meta of request
+func (c *Gate) getReqMeta(direction int, kind string) *define.ReqMeta {
+	if kind == "" {
+		kind = c.kind
+	}
+	meta := &define.ReqMeta{
+		Direction:direction,
+		Kind:kind,
+		RemoteAddr:c.address,
+	}
+	return meta
+}
+
+//get context with default timeout if no deadline
+func (c *Gate) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.GetTimeout())
+}
+
+//convert rpc or context error to sentinel error
+func (c *Gate) convertErr(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return define.ErrTimeout
+	}
+	switch status.Code(err) {
+	case codes.DeadlineExceeded:
+		return define.ErrTimeout
+	case codes.Unavailable:
+		return define.ErrGateDown
+	}
+	return err
+}
+
+//get access auth by cb
+func (c *Gate) getAccessAuth() *pb.AccessAuth {
+	c.RLock()
+	cb := c.cbForAccessAuth
+	c.RUnlock()
+	if cb == nil {
+		return nil
+	}
+	return cb(c.kind)
+}
+
+//get stream client with locker
+func (c *Gate) getStream() pb.GateService_BindStreamClient {
+	c.RLock()
+	defer c.RUnlock()
+	return c.stream
+}
+
+//clone general request, data shared
+func cloneGenReq(in *pb.GateReq) *pb.GateReq {
+	return &pb.GateReq{
+		Service:in.Service,
+		MessageId:in.MessageId,
+		Data:in.Data,
+		Address:in.Address,
+		IsAsync:in.IsAsync,
+		Auth:in.Auth,
+	}
+}
+
+//clone byte message, data shared
+func cloneByteMessage(in *pb.ByteMessage) *pb.ByteMessage {
+	return &pb.ByteMessage{
+		Service:in.Service,
+		MessageId:in.MessageId,
+		Data:in.Data,
+		Address:in.Address,
+		ConnIds:in.ConnIds,
+		CallId:in.CallId,
+	}
+}
+
+//get bind stream context with access auth metadata
+func (c *Gate) getStreamContext() context.Context {
+	auth := c.getAccessAuth()
+	if auth == nil {
+		return c.ctx
+	}
+	return metadata.AppendToOutgoingContext(
+				c.ctx,
+				define.MetaKeyOfApp, auth.App,
+				define.MetaKeyOfToken, auth.Token,
+			)
+}
+
+//update moving average latency
+func (c *Gate) updateLatency(latency time.Duration) {
+	old := atomic.LoadInt64(&c.latency)
+	if old <= 0 {
+		atomic.StoreInt64(&c.latency, int64(latency))
+		return
+	}
+	val := float64(old) * (1 - define.GateLatencyDecay) + float64(latency) * define.GateLatencyDecay
+	atomic.StoreInt64(&c.latency, int64(val))
+}
+
+//send heart beat to gate server
+//if gate server missed too many beats, close connect for reconnect
+func (c *Gate) heartBeat() bool {
+	//get heart beat option
+	c.RLock()
+	rate := c.heartBeatRate
+	maxMiss := c.heartBeatMaxMiss
+	conn := c.conn
+	stream := c.stream
+	c.RUnlock()
+
+	//basic check
+	if stream == nil || conn == nil {
+		return false
+	}
+
+	//check gate server alive
+	now := time.Now()
+	lastActive := atomic.LoadInt64(&c.lastActive)
+	if lastActive > 0 && now.Sub(time.Unix(0, lastActive)) > rate * time.Duration(maxMiss) {
+		log.Println("Gate::heartBeat, gate server missed heart beat, address:", c.address)
+		//reset active time, receive process will notify down and reconnect
+		atomic.StoreInt64(&c.lastActive, now.UnixNano())
+		conn.Close()
+		return false
+	}
+
+	//init heart beat json
+	heartBeatJson := json.NewHeartBeatJson()
+	heartBeatJson.Time = now.UnixNano()
+
+	//send to gate server
+	in := &pb.ByteMessage{
+		MessageId:define.MessageIdOfHeartBeat,
+		Data:heartBeatJson.Encode(),
+	}
+	return c.castData(in)
+}
+
+//heart beat echo from gate server
+func (c *Gate) heartBeatReceived(in *pb.ByteMessage) bool {
+	//decode heart beat json
+	heartBeatJson := json.NewHeartBeatJson()
+	if !heartBeatJson.Decode(in.Data) {
+		return false
+	}
+	if heartBeatJson.Time <= 0 {
+		//heart beat expectation of gate server
+		return c.heartBeatExpected(heartBeatJson)
+	}
+
+	//update rtt
+	rtt := time.Now().UnixNano() - heartBeatJson.Time
+	atomic.StoreInt64(&c.rtt, rtt)
+	return true
+}
+
+//heart beat expectation from gate server after node up
+//if beat slower than window of gate server, follow rate of gate server
+func (c *Gate) heartBeatExpected(heartBeatJson *json.HeartBeatJson) bool {
+	if heartBeatJson.Rate <= 0 || heartBeatJson.MaxMiss <= 0 {
+		return false
+	}
+	rate := time.Duration(heartBeatJson.Rate) * time.Millisecond
+	window := rate * time.Duration(heartBeatJson.MaxMiss)
+
+	c.Lock()
+	defer c.Unlock()
+	if c.heartBeatRate < window {
+		return true
+	}
+	log.Println("Gate::heartBeatExpected, beat rate", c.heartBeatRate,
+				"out of gate server window", window, ", follow rate", rate)
+	c.heartBeatRate = rate
+	c.heartBeatTicker.Reset(rate)
+	return true
+}
+
+//player bind or unbind from gate server
+func (c *Gate) bindOrUnbind(in *pb.ByteMessage) bool {
+	//basic check
+	if c.cbForBind == nil {
+		return false
+	}
+
+	//decode bind json
+	bindJson := json.NewBindJson()
+	if !bindJson.Decode(in.Data) {
+		return false
+	}
+
+	//call cb
+	return c.cbForBind(c.address, bindJson)
+}
+
+//async general response from gate server
+func (c *Gate) asyncRespReceived(in *pb.ByteMessage) bool {
+	//decode async response json
+	asyncRespJson := json.NewAsyncRespJson()
+	if !asyncRespJson.Decode(in.Data) {
+		return false
+	}
+
+	//get and remove pending request
+	c.Lock()
+	req, ok := c.asyncMap[asyncRespJson.ReqId]
+	delete(c.asyncMap, asyncRespJson.ReqId)
+	c.Unlock()
+	if !ok {
+		//request has been expired
+		return false
+	}
+
+	//init response
+	resp := &pb.GateResp{
+		Service:asyncRespJson.Service,
+		MessageId:asyncRespJson.MessageId,
+		Data:asyncRespJson.Data,
+		ErrorCode:asyncRespJson.ErrorCode,
+		ErrorMessage:asyncRespJson.ErrorMessage,
+	}
+	return c.asyncRespCallback(asyncRespJson.ReqId, req, resp)
+}
+
+//stream call response from gate server
+func (c *Gate) callRespReceived(in *pb.ByteMessage) bool {
+	//decode call json
+	callJson := json.NewCallJson()
+	if !callJson.Decode(in.Data) {
+		return false
+	}
+
+	//get and remove pending call
+	c.Lock()
+	replyChan, ok := c.callMap[callJson.Seq]
+	delete(c.callMap, callJson.Seq)
+	c.Unlock()
+	if !ok {
+		//caller has been gone
+		return false
+	}
+
+	//send to waiter
+	replyChan <- &pb.ByteMessage{
+		Service:in.Service,
+		MessageId:callJson.MessageId,
+		Data:callJson.Data,
+		Address:c.address,
+	}
+	return true
+}
+
+//request from sub service
+//call cb and reply result to sub service
+func (c *Gate) serviceReqReceived(in *pb.ByteMessage) bool {
+	//try catch panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Gate::serviceReqReceived panic, err:", err)
+		}
+	}()
+
+	//decode call json
+	callJson := json.NewCallJson()
+	if !callJson.Decode(in.Data) {
+		return false
+	}
+
+	//init reply json
+	replyJson := json.NewCallJson()
+	replyJson.Seq = callJson.Seq
+
+	//call cb
+	c.RLock()
+	cb := c.cbForServiceReq
+	c.RUnlock()
+	if cb != nil {
+		resp := cb(c.address, &pb.ByteMessage{
+			Service:in.Service,
+			MessageId:callJson.MessageId,
+			Data:callJson.Data,
+			Address:c.address,
+			ConnIds:in.ConnIds,
+		})
+		if resp != nil {
+			replyJson.MessageId = resp.MessageId
+			replyJson.Data = resp.Data
+		}else{
+			replyJson.Error = "invalid response"
+		}
+	}else{
+		replyJson.Error = "no cb for service request"
+	}
+
+	//reply to sub service
+	return c.CastData(&pb.ByteMessage{
+		Service:in.Service,
+		MessageId:define.MessageIdOfServiceResp,
+		Data:replyJson.Encode(),
+	})
+}
+
+//node status received from gate server
+func (c *Gate) statusReceived(in *pb.ByteMessage) bool {
+	statusJson := json.NewStatusJson()
+	if !statusJson.Decode(in.Data) {
+		return false
+	}
+	return c.updateStatus(pb.NodeStatus(statusJson.Status))
+}
+
+//update node status, notify outside if changed
+func (c *Gate) updateStatus(status pb.NodeStatus) bool {
+	old := pb.NodeStatus(atomic.SwapInt32(&c.status, int32(status)))
+	if old == status {
+		return false
+	}
+	if c.cbForStatusChanged != nil {
+		c.cbForStatusChanged(c.kind, c.address, old, status)
+	}
+	return true
+}
+
+//cancel all pending stream calls
+func (c *Gate) cleanCall() {
+	c.Lock()
+	defer c.Unlock()
+	for seq, replyChan := range c.callMap {
+		close(replyChan)
+		delete(c.callMap, seq)
+	}
+}
+
+//clean pending async requests, cb with error response
+//if all is false, only clean expired requests
+func (c *Gate) cleanAsyncReq(all bool, message string) {
+	var (
+		now = time.Now()
+		removed = make(map[uint64]*asyncReq)
+	)
+
+	//remove from map with locker
+	c.Lock()
+	for reqId, req := range c.asyncMap {
+		if all || now.After(req.expire) {
+			removed[reqId] = req
+			delete(c.asyncMap, reqId)
+		}
+	}
+	c.Unlock()
+
+	//notify outside
+	for reqId, req := range removed {
+		resp := &pb.GateResp{
+			Service:req.service,
+			MessageId:req.messageId,
+			ErrorCode:define.AsyncRespErrCode,
+			ErrorMessage:message,
+		}
+		c.asyncRespCallback(reqId, req, resp)
+	}
+}
+
+//call cb for async response
+func (c *Gate) asyncRespCallback(
+				reqId uint64,
+				req *asyncReq,
+				resp *pb.GateResp,
+			) bool {
+	//try catch panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Gate::asyncRespCallback panic, err:", err)
+		}
+	}()
+
+	//request cb first
+	if req.cb != nil {
+		req.cb(resp)
+		return true
+	}
+
+	//cb for async response
+	c.RLock()
+	cb := c.cbForAsyncResp
+	c.RUnlock()
+	if cb == nil {
+		return false
+	}
+	return cb(c.address, reqId, resp)
+}
+
 //notify current node to gate server
 func (c *Gate) notifyServer() bool {
 	//init node json
 	nodeJson := json.NewNodeJson()
 	nodeJson.Kind = c.kind
+	if len(c.tags) > 0 {
+		//the first tag as unique node tag
+		nodeJson.Tag = c.tags[0]
+	}
 
 	//init byte message
 	byteMessage := pb.ByteMessage{
@@ -258,11 +1343,12 @@ func (c *Gate) notifyServer() bool {
 	}
 
 	//send to gate server
-	if c.stream == nil {
+	stream := c.getStream()
+	if stream == nil {
 		return false
 	}
 
-	err := c.stream.Send(&byteMessage)
+	err := stream.Send(&byteMessage)
 	if err != nil {
 		log.Println("Gate::notifyServer failed, err:", err.Error())
 		return false
@@ -278,21 +1364,47 @@ func (c *Gate) connect(isReConn bool) bool {
 		err error
 	)
 
-	//release resource for reconnect
+	//only one connect in progress, reconnect may be triggered by
+	//receive process and status check of client at the same time.
+	c.Lock()
+	if c.connecting || c.needQuit {
+		c.Unlock()
+		return false
+	}
+	if isReConn && c.IsActive() {
+		//has been reconnected
+		c.Unlock()
+		return true
+	}
+	c.connecting = true
+	oldConn := c.conn
 	if isReConn {
-		//release old connect
-		if c.conn != nil {
-			c.Lock()
-			c.conn.Close()
-			c.conn = nil
-			c.Unlock()
-		}
+		c.conn = nil
+	}
+	c.Unlock()
+	defer func() {
+		c.Lock()
+		c.connecting = false
+		c.Unlock()
+	}()
+
+	//release old connect for reconnect
+	if isReConn && oldConn != nil {
+		oldConn.Close()
+	}
+
+	//init transport credentials
+	creds := grpc.WithInsecure()
+	if c.tlsLoader != nil {
+		creds = grpc.WithTransportCredentials(
+					credentials.NewTLS(c.tlsLoader.GetClientConfig()),
+				)
 	}
 
 	//try connect gate server
 	conn, err := grpc.Dial(
 		c.address,
-		grpc.WithInsecure(),
+		creds,
 	)
 	if err != nil {
 		log.Println("Gate::interInit, can't reconnect gate, err:", err.Error())
@@ -303,21 +1415,27 @@ func (c *Gate) connect(isReConn bool) bool {
 	client := pb.NewGateServiceClient(conn)
 	if client == nil {
 		log.Println("Gate::interInit, init stream failed")
+		conn.Close()
 		return false
 	}
 
 	//try create stream of both side
 	tryTimes := 0
 	for {
-		stream, err = client.BindStream(c.ctx)
+		stream, err = client.BindStream(c.getStreamContext())
 		if err == nil {
 			break
 		}
 		if err != nil && tryTimes >= define.GateBindTryTimes {
 			//too many errors, need break
+			conn.Close()
 			return false
 		}
-		if c.needQuit {
+		c.RLock()
+		needQuit := c.needQuit
+		c.RUnlock()
+		if needQuit {
+			conn.Close()
 			return false
 		}
 		tryTimes++
@@ -335,20 +1453,45 @@ func (c *Gate) connect(isReConn bool) bool {
 	c.stream = stream
 	c.client = client
 	c.Unlock()
+	atomic.StoreInt64(&c.lastActive, time.Now().UnixNano())
+	atomic.StoreInt32(&c.active, 1)
+	c.updateStatus(pb.NodeStatus_NODE_UP)
 
 	//notify gate server
 	c.notifyServer()
 
 	//spawn new process for receive stream data
-	go c.receiveGateStream()
+	recvChan := make(chan struct{})
+	c.Lock()
+	c.recvChan = recvChan
+	c.Unlock()
+	go c.receiveGateStream(recvChan)
 
 	return true
 }
 
+//send queued request in flush, count dropped one
+//queued requests will be dropped after context done
+func (c *Gate) flushReq(flush *gateFlush, req *pb.ByteMessage) {
+	if flush.ctx.Err() != nil || !c.castData(req) {
+		flush.dropped++
+	}
+}
+
+//finish flush, close send side and notify dropped count
+func (c *Gate) flushDone(flush *gateFlush) {
+	if stream := c.getStream(); stream != nil {
+		stream.CloseSend()
+	}
+	flush.droppedChan <- flush.dropped
+}
+
 //run main process
+//queued requests are sent by main loop, also for flush before quit
 func (c *Gate) runMainProcess() {
 	var (
 		req pb.ByteMessage
+		flush *gateFlush
 		needQuit, isOk bool
 	)
 
@@ -357,6 +1500,7 @@ func (c *Gate) runMainProcess() {
 		if err := recover(); err != nil {
 			log.Println("Gate:runMainProcess panic, err:", err)
 		}
+		c.heartBeatTicker.Stop()
 		//close chan
 		close(c.reqChan)
 		close(c.closeChan)
@@ -367,19 +1511,32 @@ func (c *Gate) runMainProcess() {
 		if needQuit {
 			break
 		}
+		if flush != nil && len(c.reqChan) <= 0 {
+			//all queued requests flushed
+			c.flushDone(flush)
+			break
+		}
 		select {
 		case req, isOk = <- c.reqChan://cast data to gate server
 			if isOk {
-				c.castData(&req)
+				if flush != nil {
+					c.flushReq(flush, &req)
+				}else{
+					c.castData(&req)
+				}
+				c.queue.Check(len(c.reqChan))
 			}
+		case <- c.heartBeatTicker.C://heart beat
+			c.heartBeat()
+			c.cleanAsyncReq(false, "async request timeout")
+		case flush = <- c.flushChan://flush before quit
 		case <- c.closeChan:
+			if flush != nil {
+				//quit in flush, left requests dropped
+				flush.dropped += len(c.reqChan)
+				c.flushDone(flush)
+			}
 			needQuit = true
 		}
 	}
 }
-
-//inter init
-func (c *Gate) interInit() {
-	//connect gate server
-	go c.connect(false)
-}
\ No newline at end of file
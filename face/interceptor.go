@@ -0,0 +1,104 @@
+package face
+
+import (
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/iface"
+	pb "github.com/andyzhou/tinygate/proto"
+	"sync"
+)
+
+/*
+ * interceptor face, implement of IInterceptor
+ * - used at client and service side
+ * - stream and general request chain
+ * - run in added order, first added is the outermost one
+ */
+
+//face info
+type Interceptor struct {
+	streams []iface.StreamInterceptor
+	gens []iface.GenInterceptor
+	sync.RWMutex
+}
+
+//construct
+func NewInterceptor() *Interceptor {
+	//self init
+	this := &Interceptor{
+		streams:make([]iface.StreamInterceptor, 0),
+		gens:make([]iface.GenInterceptor, 0),
+	}
+	return this
+}
+
+//////////////////////
+//implement of IInterceptor
+//////////////////////
+
+//add stream interceptor
+func (f *Interceptor) AddStream(interceptor iface.StreamInterceptor) bool {
+	if interceptor == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.streams = append(f.streams, interceptor)
+	return true
+}
+
+//add general request interceptor
+func (f *Interceptor) AddGen(interceptor iface.GenInterceptor) bool {
+	if interceptor == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.gens = append(f.gens, interceptor)
+	return true
+}
+
+//run stream data pass chain, final handler called at last
+func (f *Interceptor) InterceptStream(
+				meta *define.ReqMeta,
+				in *pb.ByteMessage,
+				final iface.StreamHandler,
+			) error {
+	//get interceptors
+	f.RLock()
+	streams := f.streams
+	f.RUnlock()
+
+	//chain from inner to outer
+	handler := final
+	for i := len(streams) - 1; i >= 0; i-- {
+		interceptor := streams[i]
+		next := handler
+		handler = func(meta *define.ReqMeta, in *pb.ByteMessage) error {
+			return interceptor(meta, in, next)
+		}
+	}
+	return handler(meta, in)
+}
+
+//run general request pass chain, final handler called at last
+func (f *Interceptor) InterceptGen(
+				meta *define.ReqMeta,
+				in *pb.GateReq,
+				final iface.GenHandler,
+			) (*pb.GateResp, error) {
+	//get interceptors
+	f.RLock()
+	gens := f.gens
+	f.RUnlock()
+
+	//chain from inner to outer
+	handler := final
+	for i := len(gens) - 1; i >= 0; i-- {
+		interceptor := gens[i]
+		next := handler
+		handler = func(meta *define.ReqMeta, in *pb.GateReq) (*pb.GateResp, error) {
+			return interceptor(meta, in, next)
+		}
+	}
+	return handler(meta, in)
+}
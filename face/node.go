@@ -1,9 +1,15 @@
 package face
 
 import (
+	"fmt"
+	"github.com/andyzhou/tinygate/define"
 	"github.com/andyzhou/tinygate/iface"
+	"github.com/andyzhou/tinygate/json"
 	pb "github.com/andyzhou/tinygate/proto"
+	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 /*
@@ -16,7 +22,15 @@ import (
  //face info
  type Node struct {
  	cbForClientNodeDown func(remoteAddr string) bool
+ 	cbForQueueHighWater func(remoteAddr string, depth int, over bool) bool
+ 	queueOption *define.QueueOption //response queue option of client nodes, optional
  	serviceMap map[string]iface.IService //client service map, remoteAddr -> IService
+	identityMap map[string]string //client node identity map, kind/tag -> remoteAddr
+	status int32 //status of current service node, `pb.NodeStatus`
+ 	heartBeatRate time.Duration //expected heart beat rate of client node
+ 	heartBeatMaxMiss int //max missed heart beats before client node down
+ 	heartBeatTicker *time.Ticker
+ 	closeChan chan bool
  	sync.RWMutex
  }
 
@@ -25,8 +39,17 @@ func NewNode() *Node {
 	//self init
 	this := &Node{
 		serviceMap:make(map[string]iface.IService),
+		identityMap:make(map[string]string),
+		status:int32(pb.NodeStatus_NODE_ACTIVE),
+		heartBeatRate:time.Second * define.HeartBeatRate,
+		heartBeatMaxMiss:define.HeartBeatMaxMiss,
+		heartBeatTicker:time.NewTicker(time.Second * define.HeartBeatRate),
+		closeChan:make(chan bool, 1),
 	}
 
+	//spawn main process
+	go this.runMainProcess()
+
 	return this
 }
 
@@ -36,30 +59,98 @@ func NewNode() *Node {
 
 //quit
 func (f *Node) Quit() {
-	if f.serviceMap != nil {
-		for _, service := range f.serviceMap {
-			service.Quit()
+	//try catch panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Node:Quit panic, err:", err)
 		}
+	}()
+
+	for _, service := range f.GetAllService() {
+		service.Quit()
 	}
+
+	//send to close chan
+	f.closeChan <- true
 }
 
 //get remote node service
+//address is remote address or node identity `kind/tag`
 func (f *Node) GetService(
 					address string,
 				) iface.IService {
 	if address == "" {
 		return nil
 	}
+	f.RLock()
+	defer f.RUnlock()
 	service, ok := f.serviceMap[address]
+	if ok {
+		return service
+	}
+	remoteAddr, ok := f.identityMap[address]
+	if !ok {
+		return nil
+	}
+	service, ok = f.serviceMap[remoteAddr]
 	if !ok {
 		return nil
 	}
 	return service
 }
 
+//get remote address of client node by identity `kind/tag`
+func (f *Node) GetRemoteAddr(identity string) string {
+	if identity == "" {
+		return ""
+	}
+	f.RLock()
+	defer f.RUnlock()
+	return f.identityMap[identity]
+}
+
+//register client node identity by node up handshake
+//the newer connect of same identity replace the older one
+func (f *Node) RegisterNode(
+					remoteAddress, kind, tag string,
+				) bool {
+	//basic check
+	if remoteAddress == "" || kind == "" {
+		return false
+	}
+
+	//get service with locker
+	f.Lock()
+	defer f.Unlock()
+	service, ok := f.serviceMap[remoteAddress]
+	if !ok {
+		return false
+	}
+
+	//remove old identity of this connect
+	oldId := GenNodeId(service.GetKind(), service.GetTag())
+	if oldId != "" && f.identityMap[oldId] == remoteAddress {
+		delete(f.identityMap, oldId)
+	}
+
+	//set new identity
+	service.SetNode(kind, tag)
+	identity := GenNodeId(kind, tag)
+	if identity != "" {
+		f.identityMap[identity] = remoteAddress
+	}
+	return true
+}
+
 //get all service
 func (f *Node) GetAllService() map[string]iface.IService {
-	return f.serviceMap
+	f.RLock()
+	defer f.RUnlock()
+	result := make(map[string]iface.IService, len(f.serviceMap))
+	for addr, service := range f.serviceMap {
+		result[addr] = service
+	}
+	return result
 }
 
 //rpc client node down
@@ -71,15 +162,27 @@ func (f *Node) ClientNodeDown(
 		return false
 	}
 
+	//remove with locker
+	f.Lock()
+	service, ok := f.serviceMap[remoteAddress]
+	delete(f.serviceMap, remoteAddress)
+	if ok {
+		//keep identity if it has been taken by newer connect
+		identity := GenNodeId(service.GetKind(), service.GetTag())
+		if identity != "" && f.identityMap[identity] == remoteAddress {
+			delete(f.identityMap, identity)
+		}
+	}
+	f.Unlock()
+	if !ok {
+		return false
+	}
+	service.Quit()
+
 	//notify outside
 	if f.cbForClientNodeDown != nil {
 		f.cbForClientNodeDown(remoteAddress)
 	}
-
-	//remove with locker
-	f.Lock()
-	defer f.Unlock()
-	delete(f.serviceMap, remoteAddress)
 	return true
 }
 
@@ -108,11 +211,97 @@ func (f *Node) ClientNodeUp(
 
 	//add into map with locker
 	f.Lock()
-	defer f.Unlock()
+	if f.queueOption != nil {
+		service.SetQueueOption(f.queueOption)
+	}
+	if f.cbForQueueHighWater != nil {
+		service.SetCBForQueueHighWater(f.cbForQueueHighWater)
+	}
 	f.serviceMap[remoteAddress] = service
+	f.Unlock()
+
+	//notify current status to client node
+	service.SendClientResp(f.genStatusMessage())
+	return true
+}
+
+//set status of current service node
+//status will be notified to all client nodes
+func (f *Node) SetStatus(status pb.NodeStatus) bool {
+	old := atomic.SwapInt32(&f.status, int32(status))
+	if old == int32(status) {
+		return false
+	}
+
+	//notify all client nodes
+	message := f.genStatusMessage()
+	for _, service := range f.GetAllService() {
+		service.SendClientResp(message)
+	}
+	return true
+}
+
+//get status of current service node
+func (f *Node) GetStatus() pb.NodeStatus {
+	return pb.NodeStatus(atomic.LoadInt32(&f.status))
+}
+
+//set heart beat option
+//client node down after `maxMiss` beats without any data,
+//the option will be sent to client node after node up,
+//client node beat slower than `rate * maxMiss` will follow `rate`.
+func (f *Node) SetHeartBeat(rate time.Duration, maxMiss int) bool {
+	if rate <= 0 || maxMiss <= 0 {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.heartBeatRate = rate
+	f.heartBeatMaxMiss = maxMiss
+	f.heartBeatTicker.Reset(rate)
+	return true
+}
+
+//get heart beat option
+func (f *Node) GetHeartBeat() (time.Duration, int) {
+	f.RLock()
+	defer f.RUnlock()
+	return f.heartBeatRate, f.heartBeatMaxMiss
+}
+
+//set response queue option for all client nodes
+func (f *Node) SetQueueOption(option *define.QueueOption) bool {
+	//check option by a temp guard
+	if !NewQueueGuard().SetOption(option) {
+		return false
+	}
+
+	//sync with locker
+	f.Lock()
+	defer f.Unlock()
+	queueOption := *option
+	f.queueOption = &queueOption
+	for _, service := range f.serviceMap {
+		service.SetQueueOption(f.queueOption)
+	}
 	return true
 }
 
+//set cb for response queue of client node crossing high water mark
+func (f *Node) SetCBForQueueHighWater(
+				cb func(remoteAddr string, depth int, over bool) bool,
+			) bool {
+	if cb == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.cbForQueueHighWater = cb
+	for _, service := range f.serviceMap {
+		service.SetCBForQueueHighWater(cb)
+	}
+	return true
+}
 
 //set cb for client node down
 func (f *Node) SetCBForClientNodeDown(cb func(remoteAddr string) bool) bool {
@@ -121,4 +310,73 @@ func (f *Node) SetCBForClientNodeDown(cb func(remoteAddr string) bool) bool {
 	}
 	f.cbForClientNodeDown = cb
 	return true
-}
\ No newline at end of file
+}
+
+//gen client node identity by kind and tag
+//return empty if without tag, as kind only is not unique
+func GenNodeId(kind, tag string) string {
+	if kind == "" || tag == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s%s%s", kind, define.NodeIdSeparator, tag)
+}
+
+////////////////
+//private func
+////////////////
+
+//gen status message for client node
+func (f *Node) genStatusMessage() *pb.ByteMessage {
+	statusJson := json.NewStatusJson()
+	statusJson.Status = atomic.LoadInt32(&f.status)
+	return &pb.ByteMessage{
+		MessageId:define.MessageIdOfNodeStatus,
+		Data:statusJson.Encode(),
+	}
+}
+
+//check client nodes alive by heart beat
+func (f *Node) checkHeartBeat() {
+	var (
+		downAddrs = make([]string, 0)
+		now = time.Now()
+	)
+
+	//find expired client nodes
+	f.RLock()
+	timeout := f.heartBeatRate * time.Duration(f.heartBeatMaxMiss)
+	for addr, service := range f.serviceMap {
+		if now.Sub(service.GetLastActive()) > timeout {
+			downAddrs = append(downAddrs, addr)
+		}
+	}
+	f.RUnlock()
+
+	//client node down, bind stream will be closed
+	for _, addr := range downAddrs {
+		log.Println("Node::checkHeartBeat, client node missed heart beat, address:", addr)
+		f.ClientNodeDown(addr)
+	}
+}
+
+//run main process
+func (f *Node) runMainProcess() {
+	//defer
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Node:runMainProcess panic, err:", err)
+		}
+		f.heartBeatTicker.Stop()
+		close(f.closeChan)
+	}()
+
+	//loop
+	for {
+		select {
+		case <- f.heartBeatTicker.C:
+			f.checkHeartBeat()
+		case <- f.closeChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package face
+
+import (
+	"testing"
+
+	pb "github.com/andyzhou/tinygate/proto"
+)
+
+//bind stream of client node, drop sent message
+type testStream struct {
+	pb.GateService_BindStreamServer
+}
+
+func (s *testStream) Send(in *pb.ByteMessage) error {
+	return nil
+}
+
+//new node with client nodes up
+func newTestNode(t *testing.T, remoteAddrs ...string) *Node {
+	node := NewNode()
+	t.Cleanup(node.Quit)
+	for _, remoteAddr := range remoteAddrs {
+		var stream pb.GateService_BindStreamServer = &testStream{}
+		if !node.ClientNodeUp(remoteAddr, &stream) {
+			t.Fatalf("client node %s up failed", remoteAddr)
+		}
+	}
+	return node
+}
+
+func TestGenNodeId(t *testing.T) {
+	if id := GenNodeId("chat", "chat-1"); id != "chat/chat-1" {
+		t.Fatalf("node id = %s, want chat/chat-1", id)
+	}
+	if GenNodeId("chat", "") != "" || GenNodeId("", "chat-1") != "" {
+		t.Fatal("node id without kind or tag should be empty")
+	}
+}
+
+func TestNodeRegister(t *testing.T) {
+	node := newTestNode(t, "a:1")
+	if node.RegisterNode("b:1", "chat", "chat-1") || node.RegisterNode("a:1", "", "chat-1") {
+		t.Fatal("register unknown address or empty kind should fail")
+	}
+	if !node.RegisterNode("a:1", "chat", "chat-1") {
+		t.Fatal("register client node failed")
+	}
+
+	//get service by identity or remote address
+	service := node.GetService("chat/chat-1")
+	if service == nil || service.GetRemoteAddr() != "a:1" || service != node.GetService("a:1") {
+		t.Fatal("service by identity should be the one of remote address")
+	}
+	if service.GetKind() != "chat" || service.GetTag() != "chat-1" {
+		t.Fatalf("kind/tag = %s/%s, want chat/chat-1", service.GetKind(), service.GetTag())
+	}
+	if node.GetRemoteAddr("chat/chat-1") != "a:1" {
+		t.Fatal("remote address of identity should be a:1")
+	}
+
+	//re-register with new tag, old identity removed
+	node.RegisterNode("a:1", "chat", "chat-2")
+	if node.GetService("chat/chat-1") != nil || node.GetRemoteAddr("chat/chat-2") != "a:1" {
+		t.Fatal("old identity should be replaced")
+	}
+
+	//kind only is not unique, no identity
+	node.RegisterNode("a:1", "chat", "")
+	if node.GetRemoteAddr("chat/chat-2") != "" || node.GetService("chat") != nil {
+		t.Fatal("client node without tag should have no identity")
+	}
+}
+
+func TestNodeReconnect(t *testing.T) {
+	node := newTestNode(t, "a:1", "a:2")
+	node.RegisterNode("a:1", "chat", "chat-1")
+
+	//newer connect take the identity
+	node.RegisterNode("a:2", "chat", "chat-1")
+	if node.GetRemoteAddr("chat/chat-1") != "a:2" {
+		t.Fatal("identity should move to newer connect")
+	}
+
+	//older connect down, identity kept
+	node.ClientNodeDown("a:1")
+	if service := node.GetService("chat/chat-1"); service == nil || service.GetRemoteAddr() != "a:2" {
+		t.Fatal("identity should be kept for newer connect")
+	}
+
+	//newer connect down, identity removed
+	node.ClientNodeDown("a:2")
+	if node.GetService("chat/chat-1") != nil || node.GetRemoteAddr("chat/chat-1") != "" {
+		t.Fatal("identity should be removed with its connect")
+	}
+}
@@ -0,0 +1,190 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"github.com/andyzhou/tinygate/define"
+	pb "github.com/andyzhou/tinygate/proto"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+ * queue guard face
+ * - apply overflow policy for chan queue of gate and service
+ * - block with timeout, drop newest, drop oldest or reject
+ * - queue depth gauge and high water mark notify
+ */
+
+//queue guard info
+type QueueGuard struct {
+	option define.QueueOption
+	dropped uint64 //dropped messages by overflow policy
+	overHighWater int32 //depth over high water mark or not
+	cbForHighWater func(depth int, over bool)
+	sync.RWMutex
+}
+
+//construct
+func NewQueueGuard() *QueueGuard {
+	this := &QueueGuard{
+		option:define.QueueOption{
+			Policy:define.OverflowOfBlock,
+		},
+	}
+	return this
+}
+
+//set queue option
+func (f *QueueGuard) SetOption(option *define.QueueOption) bool {
+	//basic check
+	if option == nil || option.BlockTimeout < 0 || option.HighWater < 0 {
+		return false
+	}
+	if option.Policy < define.OverflowOfBlock || option.Policy > define.OverflowOfReject {
+		return false
+	}
+
+	//sync with locker
+	f.Lock()
+	defer f.Unlock()
+	f.option = *option
+	return true
+}
+
+//get queue option
+func (f *QueueGuard) GetOption() define.QueueOption {
+	f.RLock()
+	defer f.RUnlock()
+	return f.option
+}
+
+//set cb for queue depth crossing high water mark
+//over is false when depth fall back to half of high water mark
+func (f *QueueGuard) SetCBForHighWater(cb func(depth int, over bool)) bool {
+	if cb == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.cbForHighWater = cb
+	return true
+}
+
+//get queue stat
+func (f *QueueGuard) GetStat(queue chan pb.ByteMessage) define.QueueStat {
+	stat := define.QueueStat{
+		Depth:len(queue),
+		Capacity:cap(queue),
+		Dropped:atomic.LoadUint64(&f.dropped),
+		OverHighWater:atomic.LoadInt32(&f.overHighWater) == 1,
+	}
+	return stat
+}
+
+//push message into queue by overflow policy
+//context is optional, used for block policy,
+//return `ErrQueueFull` if dropped, rejected, block timeout,
+//or no room after drop oldest,
+//return `ErrTimeout` or error of context if context done
+func (f *QueueGuard) Push(
+				ctx context.Context,
+				queue chan pb.ByteMessage,
+				in *pb.ByteMessage,
+			) error {
+	//check high water mark after push
+	defer func() {
+		f.Check(len(queue))
+	}()
+
+	//try push without wait first
+	select {
+	case queue <- *in:
+		return nil
+	default:
+	}
+
+	//queue is full, do relate opt by policy
+	option := f.GetOption()
+	switch option.Policy {
+	case define.OverflowOfDropNewest:
+		{
+			atomic.AddUint64(&f.dropped, 1)
+			return define.ErrQueueFull
+		}
+	case define.OverflowOfDropOldest:
+		{
+			//drop one oldest then try once more,
+			//room may be taken by concurrent producer
+			select {
+			case <- queue:
+				atomic.AddUint64(&f.dropped, 1)
+			default:
+			}
+			select {
+			case queue <- *in:
+				return nil
+			default:
+				return define.ErrQueueFull
+			}
+		}
+	case define.OverflowOfReject:
+		{
+			return define.ErrQueueFull
+		}
+	}
+
+	//block until queue room, block timeout or context done
+	//without both, use default timeout, never block forever
+	var (
+		timeoutChan <-chan time.Time
+		doneChan <-chan struct{}
+	)
+	blockTimeout := option.BlockTimeout
+	if blockTimeout <= 0 && ctx == nil {
+		blockTimeout = time.Second * define.QueueBlockTimeout
+	}
+	if blockTimeout > 0 {
+		timer := time.NewTimer(blockTimeout)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+	if ctx != nil {
+		doneChan = ctx.Done()
+	}
+	select {
+	case queue <- *in:
+		return nil
+	case <- timeoutChan:
+		return define.ErrQueueFull
+	case <- doneChan:
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return define.ErrTimeout
+		}
+		return ctx.Err()
+	}
+}
+
+//check queue depth with high water mark
+//cb called when depth crossing high water mark or fall back to half of it
+func (f *QueueGuard) Check(depth int) {
+	f.RLock()
+	highWater := f.option.HighWater
+	cb := f.cbForHighWater
+	f.RUnlock()
+	if highWater <= 0 {
+		return
+	}
+	if depth >= highWater {
+		if atomic.CompareAndSwapInt32(&f.overHighWater, 0, 1) && cb != nil {
+			cb(depth, true)
+		}
+		return
+	}
+	if depth <= highWater / 2 {
+		if atomic.CompareAndSwapInt32(&f.overHighWater, 1, 0) && cb != nil {
+			cb(depth, false)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andyzhou/tinygate/define"
+	pb "github.com/andyzhou/tinygate/proto"
+)
+
+//new queue guard with option
+func newTestQueueGuard(t *testing.T, option *define.QueueOption) *QueueGuard {
+	guard := NewQueueGuard()
+	if !guard.SetOption(option) {
+		t.Fatalf("set option %+v failed", option)
+	}
+	return guard
+}
+
+//pop message id from queue
+func popMessageId(queue chan pb.ByteMessage) uint32 {
+	return (<- queue).MessageId
+}
+
+func TestQueueGuardSetOption(t *testing.T) {
+	guard := NewQueueGuard()
+	invalid := []*define.QueueOption{
+		nil,
+		{Policy:define.OverflowOfReject + 1},
+		{Policy:define.OverflowOfBlock - 1},
+		{BlockTimeout:-1},
+		{HighWater:-1},
+	}
+	for i, option := range invalid {
+		if guard.SetOption(option) {
+			t.Fatalf("case %d should be rejected", i)
+		}
+	}
+	if guard.GetOption().Policy != define.OverflowOfBlock {
+		t.Fatal("default policy should be block")
+	}
+}
+
+func TestQueueGuardDropNewest(t *testing.T) {
+	guard := newTestQueueGuard(t, &define.QueueOption{Policy:define.OverflowOfDropNewest})
+	queue := make(chan pb.ByteMessage, 1)
+	if err := guard.Push(nil, queue, &pb.ByteMessage{MessageId:1}); err != nil {
+		t.Fatalf("first push failed, err:%v", err)
+	}
+	err := guard.Push(nil, queue, &pb.ByteMessage{MessageId:2})
+	if !errors.Is(err, define.ErrQueueFull) {
+		t.Fatalf("push to full queue got %v, want ErrQueueFull", err)
+	}
+	if messageId := popMessageId(queue); messageId != 1 {
+		t.Fatalf("queued message %d, want 1", messageId)
+	}
+	if stat := guard.GetStat(queue); stat.Dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", stat.Dropped)
+	}
+}
+
+func TestQueueGuardDropOldest(t *testing.T) {
+	guard := newTestQueueGuard(t, &define.QueueOption{Policy:define.OverflowOfDropOldest})
+	queue := make(chan pb.ByteMessage, 2)
+	for i := uint32(1); i <= 3; i++ {
+		if err := guard.Push(nil, queue, &pb.ByteMessage{MessageId:i}); err != nil {
+			t.Fatalf("push %d failed, err:%v", i, err)
+		}
+	}
+	first, second := popMessageId(queue), popMessageId(queue)
+	if first != 2 || second != 3 {
+		t.Fatalf("queued messages %d,%d, want 2,3", first, second)
+	}
+	if stat := guard.GetStat(queue); stat.Dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", stat.Dropped)
+	}
+}
+
+func TestQueueGuardDropOldestConcurrent(t *testing.T) {
+	const (
+		producers = 8
+		pushes = 1000
+	)
+	var (
+		pushed, full, consumed int64
+		wg sync.WaitGroup
+	)
+	guard := newTestQueueGuard(t, &define.QueueOption{Policy:define.OverflowOfDropOldest})
+	queue := make(chan pb.ByteMessage, 4)
+
+	//consumer
+	done := make(chan struct{})
+	consumerDone := make(chan struct{})
+	go func() {
+		defer close(consumerDone)
+		for {
+			select {
+			case <- queue:
+				atomic.AddInt64(&consumed, 1)
+			case <- done:
+				return
+			}
+		}
+	}()
+
+	//producers
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < pushes; j++ {
+				err := guard.Push(nil, queue, &pb.ByteMessage{})
+				switch {
+				case err == nil:
+					atomic.AddInt64(&pushed, 1)
+				case errors.Is(err, define.ErrQueueFull):
+					atomic.AddInt64(&full, 1)
+				default:
+					t.Errorf("push got %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	<- consumerDone
+
+	//every pushed message is consumed, dropped or still queued
+	dropped := int64(guard.GetStat(queue).Dropped)
+	if pushed + full != producers * pushes {
+		t.Fatalf("pushed %d + full %d, want %d", pushed, full, producers * pushes)
+	}
+	if pushed != consumed + dropped + int64(len(queue)) {
+		t.Fatalf("pushed %d, consumed %d, dropped %d, queued %d", pushed, consumed, dropped, len(queue))
+	}
+}
+
+func TestQueueGuardReject(t *testing.T) {
+	guard := newTestQueueGuard(t, &define.QueueOption{Policy:define.OverflowOfReject})
+	queue := make(chan pb.ByteMessage, 1)
+	guard.Push(nil, queue, &pb.ByteMessage{})
+	if err := guard.Push(nil, queue, &pb.ByteMessage{}); !errors.Is(err, define.ErrQueueFull) {
+		t.Fatalf("push to full queue got %v, want ErrQueueFull", err)
+	}
+	if stat := guard.GetStat(queue); stat.Dropped != 0 || stat.Depth != 1 || stat.Capacity != 1 {
+		t.Fatalf("stat mismatch, %+v", stat)
+	}
+}
+
+func TestQueueGuardBlock(t *testing.T) {
+	guard := newTestQueueGuard(t, &define.QueueOption{
+		Policy:define.OverflowOfBlock,
+		BlockTimeout:time.Millisecond * 50,
+	})
+	queue := make(chan pb.ByteMessage, 1)
+	guard.Push(nil, queue, &pb.ByteMessage{MessageId:1})
+
+	//block timeout
+	begin := time.Now()
+	err := guard.Push(nil, queue, &pb.ByteMessage{MessageId:2})
+	if !errors.Is(err, define.ErrQueueFull) {
+		t.Fatalf("block timeout got %v, want ErrQueueFull", err)
+	}
+	if time.Since(begin) < time.Millisecond * 50 {
+		t.Fatal("push returned before block timeout")
+	}
+
+	//room released while blocking
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		<- queue
+	}()
+	if err = guard.Push(nil, queue, &pb.ByteMessage{MessageId:3}); err != nil {
+		t.Fatalf("push after room released failed, err:%v", err)
+	}
+	if messageId := popMessageId(queue); messageId != 3 {
+		t.Fatalf("queued message %d, want 3", messageId)
+	}
+}
+
+func TestQueueGuardBlockContext(t *testing.T) {
+	guard := NewQueueGuard()
+	queue := make(chan pb.ByteMessage, 1)
+	guard.Push(nil, queue, &pb.ByteMessage{})
+
+	//context deadline converted to timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond * 20)
+	defer cancel()
+	if err := guard.Push(ctx, queue, &pb.ByteMessage{}); !errors.Is(err, define.ErrTimeout) {
+		t.Fatalf("context deadline got %v, want ErrTimeout", err)
+	}
+
+	//canceled context keep its error
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := guard.Push(ctx, queue, &pb.ByteMessage{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled context got %v, want context.Canceled", err)
+	}
+}
+
+func TestQueueGuardHighWater(t *testing.T) {
+	var (
+		events []bool
+		depths []int
+	)
+	guard := newTestQueueGuard(t, &define.QueueOption{
+		Policy:define.OverflowOfReject,
+		HighWater:4,
+	})
+	guard.SetCBForHighWater(func(depth int, over bool) {
+		events = append(events, over)
+		depths = append(depths, depth)
+	})
+	queue := make(chan pb.ByteMessage, 8)
+
+	//cross high water once
+	for i := 0; i < 6; i++ {
+		guard.Push(nil, queue, &pb.ByteMessage{})
+	}
+	if len(events) != 1 || !events[0] || depths[0] != 4 {
+		t.Fatalf("events = %v, depths = %v, want one over at 4", events, depths)
+	}
+	if !guard.GetStat(queue).OverHighWater {
+		t.Fatal("stat should be over high water")
+	}
+
+	//no event until fall back to half
+	for len(queue) > 2 {
+		<- queue
+		guard.Check(len(queue))
+	}
+	if len(events) != 2 || events[1] || depths[1] != 2 {
+		t.Fatalf("events = %v, depths = %v, want back at 2", events, depths)
+	}
+	if guard.GetStat(queue).OverHighWater {
+		t.Fatal("stat should be under high water")
+	}
+}
@@ -0,0 +1,178 @@
+package face
+
+import (
+	"github.com/andyzhou/tinygate/iface"
+	pb "github.com/andyzhou/tinygate/proto"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+ * connect registry face, implement of IConnRegistry
+ * - used at gate client side
+ * - allocate connect id for front end connects
+ * - shared by all front ends and callbacks
+ */
+
+//face info
+type ConnRegistry struct {
+	connId uint32 //last allocated connect id
+	metaMap map[uint32]iface.IConnMeta //connId -> IConnMeta
+	cbsForConnClosed []func(connId uint32) bool
+	sync.RWMutex
+}
+
+//construct
+func NewConnRegistry() *ConnRegistry {
+	//self init
+	this := &ConnRegistry{
+		metaMap:make(map[uint32]iface.IConnMeta),
+	}
+	return this
+}
+
+//////////////////////
+//implement of IConnRegistry
+//////////////////////
+
+//quit
+func (f *ConnRegistry) Quit() {
+	//try catch panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("ConnRegistry:Quit panic, err:", err)
+		}
+	}()
+
+	//close all connects
+	f.Range(func(meta iface.IConnMeta) bool {
+		meta.Close()
+		return true
+	})
+}
+
+//allocate new connect id, zero is reserved
+func (f *ConnRegistry) NewConnId() uint32 {
+	connId := atomic.AddUint32(&f.connId, 1)
+	if connId == 0 {
+		connId = atomic.AddUint32(&f.connId, 1)
+	}
+	return connId
+}
+
+//add front end connect
+func (f *ConnRegistry) Add(conn iface.IConn) iface.IConnMeta {
+	//basic check
+	if conn == nil || conn.GetConnId() == 0 {
+		return nil
+	}
+
+	//init meta
+	meta := NewConnMeta(conn)
+
+	//add into map with locker
+	f.Lock()
+	defer f.Unlock()
+	f.metaMap[conn.GetConnId()] = meta
+	return meta
+}
+
+//remove closed connect
+func (f *ConnRegistry) Remove(connId uint32) bool {
+	//remove with locker
+	f.Lock()
+	_, ok := f.metaMap[connId]
+	delete(f.metaMap, connId)
+	cbs := f.cbsForConnClosed
+	f.Unlock()
+	if !ok {
+		return false
+	}
+
+	//notify outside
+	for _, cb := range cbs {
+		cb(connId)
+	}
+	return true
+}
+
+//close front end connect
+func (f *ConnRegistry) Close(connId uint32) bool {
+	meta := f.Get(connId)
+	if meta == nil {
+		return false
+	}
+	meta.Close()
+	return true
+}
+
+//cast message to front end connects by `ConnIds`
+func (f *ConnRegistry) Cast(in *pb.ByteMessage) bool {
+	//basic check
+	if in == nil || len(in.ConnIds) <= 0 {
+		return false
+	}
+
+	//write one by one
+	for _, connId := range in.ConnIds {
+		meta := f.Get(connId)
+		if meta == nil {
+			continue
+		}
+		meta.Write(in)
+	}
+	return true
+}
+
+//get connect meta by id
+func (f *ConnRegistry) Get(connId uint32) iface.IConnMeta {
+	f.RLock()
+	defer f.RUnlock()
+	meta, ok := f.metaMap[connId]
+	if !ok {
+		return nil
+	}
+	return meta
+}
+
+//loop all connect meta, stop if cb return false
+func (f *ConnRegistry) Range(cb func(meta iface.IConnMeta) bool) {
+	if cb == nil {
+		return
+	}
+
+	//copy with locker, cb may call registry again
+	f.RLock()
+	metas := make([]iface.IConnMeta, 0, len(f.metaMap))
+	for _, meta := range f.metaMap {
+		metas = append(metas, meta)
+	}
+	f.RUnlock()
+
+	//loop
+	for _, meta := range metas {
+		if !cb(meta) {
+			break
+		}
+	}
+}
+
+//get connect count
+func (f *ConnRegistry) Count() int {
+	f.RLock()
+	defer f.RUnlock()
+	return len(f.metaMap)
+}
+
+//add cb for front end connect closed
+//support multi cb, called in added order
+func (f *ConnRegistry) AddCBForConnClosed(cb func(connId uint32) bool) bool {
+	if cb == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.cbsForConnClosed = append(f.cbsForConnClosed, cb)
+	return true
+}
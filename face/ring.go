@@ -0,0 +1,121 @@
+package face
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+/*
+ * hash ring face
+ * - consistent hash with virtual nodes
+ * - used at gate client side, one ring one service kind
+ * - add or remove node only remap a small fraction of keys
+ */
+
+//face info
+type HashRing struct {
+	replicas int //virtual node count of one node
+	hashes []uint32 //sorted virtual node hashes
+	hashMap map[uint32]string //virtual node hash -> node
+	nodeMap map[string]bool //node -> true
+	sync.RWMutex
+}
+
+//construct
+func NewHashRing(replicas int) *HashRing {
+	//self init
+	this := &HashRing{
+		replicas:replicas,
+		hashes:make([]uint32, 0),
+		hashMap:make(map[uint32]string),
+		nodeMap:make(map[string]bool),
+	}
+	return this
+}
+
+//add node
+func (f *HashRing) Add(node string) bool {
+	if node == "" {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	if f.nodeMap[node] {
+		return true
+	}
+	f.nodeMap[node] = true
+	for i := 0; i < f.replicas; i++ {
+		hash := f.hash(node + "#" + strconv.Itoa(i))
+		f.hashMap[hash] = node
+		f.hashes = append(f.hashes, hash)
+	}
+	sort.Slice(f.hashes, func(i, j int) bool {
+		return f.hashes[i] < f.hashes[j]
+	})
+	return true
+}
+
+//remove node
+func (f *HashRing) Remove(node string) bool {
+	f.Lock()
+	defer f.Unlock()
+	if !f.nodeMap[node] {
+		return false
+	}
+	delete(f.nodeMap, node)
+	hashes := make([]uint32, 0, len(f.hashes))
+	for _, hash := range f.hashes {
+		if f.hashMap[hash] == node {
+			delete(f.hashMap, hash)
+			continue
+		}
+		hashes = append(hashes, hash)
+	}
+	f.hashes = hashes
+	return true
+}
+
+//get node by key
+//filter is optional, skip nodes which filter return false
+func (f *HashRing) Get(key string, filter func(node string) bool) string {
+	f.RLock()
+	defer f.RUnlock()
+	if len(f.hashes) <= 0 {
+		return ""
+	}
+
+	//find first virtual node clockwise
+	hash := f.hash(key)
+	idx := sort.Search(len(f.hashes), func(i int) bool {
+		return f.hashes[i] >= hash
+	})
+
+	//walk the ring until matched
+	for i := 0; i < len(f.hashes); i++ {
+		node := f.hashMap[f.hashes[(idx + i) % len(f.hashes)]]
+		if filter == nil || filter(node) {
+			return node
+		}
+	}
+	return ""
+}
+
+//get node count
+func (f *HashRing) Count() int {
+	f.RLock()
+	defer f.RUnlock()
+	return len(f.nodeMap)
+}
+
+////////////////
+//private func
+////////////////
+
+//hash key, use first 4 bytes of md5 like ketama
+func (f *HashRing) hash(key string) uint32 {
+	sum := md5.Sum([]byte(key))
+	return binary.BigEndian.Uint32(sum[0:4])
+}
@@ -0,0 +1,107 @@
+package face
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/andyzhou/tinygate/define"
+)
+
+func TestHashRingEmpty(t *testing.T) {
+	ring := NewHashRing(define.HashRingReplicas)
+	if got := ring.Get("k1", nil); got != "" {
+		t.Fatalf("get from empty ring = %q, want empty", got)
+	}
+	if ring.Add("") {
+		t.Fatal("add empty node should fail")
+	}
+	if ring.Remove("a:1") {
+		t.Fatal("remove unknown node should fail")
+	}
+}
+
+func TestHashRingStable(t *testing.T) {
+	ring := NewHashRing(define.HashRingReplicas)
+	for _, node := range []string{"a:1", "a:2", "a:3"} {
+		ring.Add(node)
+	}
+
+	//add twice is no-op
+	ring.Add("a:1")
+	if ring.Count() != 3 {
+		t.Fatalf("count = %d, want 3", ring.Count())
+	}
+
+	//same key same node, every node got keys
+	hits := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		node := ring.Get(key, nil)
+		if again := ring.Get(key, nil); again != node {
+			t.Fatalf("key %s got %s then %s", key, node, again)
+		}
+		hits[node]++
+	}
+	for _, node := range []string{"a:1", "a:2", "a:3"} {
+		if hits[node] <= 0 {
+			t.Fatalf("node %s got no keys, hits:%v", node, hits)
+		}
+	}
+}
+
+func TestHashRingRemap(t *testing.T) {
+	ring := NewHashRing(define.HashRingReplicas)
+	for _, node := range []string{"a:1", "a:2", "a:3"} {
+		ring.Add(node)
+	}
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		before[key] = ring.Get(key, nil)
+	}
+
+	//remove one node, only its keys move
+	ring.Remove("a:2")
+	for key, node := range before {
+		got := ring.Get(key, nil)
+		if got == "a:2" {
+			t.Fatalf("key %s still on removed node", key)
+		}
+		if node != "a:2" && got != node {
+			t.Fatalf("key %s moved from %s to %s", key, node, got)
+		}
+	}
+
+	//add back, keys return to origin node
+	ring.Add("a:2")
+	for key, node := range before {
+		if got := ring.Get(key, nil); got != node {
+			t.Fatalf("key %s on %s after add back, want %s", key, got, node)
+		}
+	}
+}
+
+func TestHashRingFilter(t *testing.T) {
+	ring := NewHashRing(define.HashRingReplicas)
+	ring.Add("a:1")
+	ring.Add("a:2")
+
+	//skip filtered node
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		got := ring.Get(key, func(node string) bool {
+			return node != "a:1"
+		})
+		if got != "a:2" {
+			t.Fatalf("key %s got %q, want a:2", key, got)
+		}
+	}
+
+	//all filtered
+	got := ring.Get("k1", func(node string) bool {
+		return false
+	})
+	if got != "" {
+		t.Fatalf("all filtered got %q, want empty", got)
+	}
+}
@@ -0,0 +1,413 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/iface"
+	pb "github.com/andyzhou/tinygate/proto"
+	"sync"
+)
+
+/*
+ * router face, implement of IRouter
+ * - used at sub service side
+ * - exact message id first, then ranges in added order
+ * - mounted sub router matched by service kind first
+ * - not found cb called if no handler matched, or reply `NotFoundErrCode`
+ * - value handler decode and encode data by codec registry
+ * - context of general request passed to context handler
+ */
+
+//stream handler of message id range
+type streamRange struct {
+	begin uint32
+	end uint32
+	handler func(remoteAddr string, in *pb.ByteMessage) bool
+}
+
+//general handler of message id range
+type genRange struct {
+	begin uint32
+	end uint32
+	handler func(ctx context.Context, in *pb.GateReq) *pb.GateResp
+}
+
+//face info
+type Router struct {
+	streamMap map[uint32]func(remoteAddr string, in *pb.ByteMessage) bool //messageId -> handler
+	streamRanges []streamRange
+	genMap map[uint32]func(ctx context.Context, in *pb.GateReq) *pb.GateResp //messageId -> handler
+	genRanges []genRange
+	subMap map[string]iface.IRouter //serviceKind -> sub router
+	codecs iface.ICodecRegistry //codec registry for value handler
+	cbForCodecError func(remoteAddr string, err error) bool
+	cbForStreamNotFound func(remoteAddr string, in *pb.ByteMessage) bool
+	cbForGenNotFound func(in *pb.GateReq) *pb.GateResp
+	sync.RWMutex
+}
+
+//construct
+func NewRouter() *Router {
+	//self init
+	this := &Router{
+		streamMap:make(map[uint32]func(remoteAddr string, in *pb.ByteMessage) bool),
+		streamRanges:make([]streamRange, 0),
+		genMap:make(map[uint32]func(ctx context.Context, in *pb.GateReq) *pb.GateResp),
+		genRanges:make([]genRange, 0),
+		subMap:make(map[string]iface.IRouter),
+	}
+	return this
+}
+
+//////////////////////
+//implement of IRouter
+//////////////////////
+
+//dispatch stream request
+//used as cb for stream request
+func (f *Router) DispatchStream(remoteAddr string, in *pb.ByteMessage) bool {
+	//basic check
+	if in == nil {
+		return false
+	}
+
+	//get sub router or handler
+	f.RLock()
+	sub := f.subMap[in.Service]
+	handler := f.getStreamHandler(in.MessageId)
+	notFound := f.cbForStreamNotFound
+	f.RUnlock()
+
+	//dispatch to sub router
+	if sub != nil {
+		return sub.DispatchStream(remoteAddr, in)
+	}
+
+	//call handler
+	if handler != nil {
+		return handler(remoteAddr, in)
+	}
+	if notFound != nil {
+		return notFound(remoteAddr, in)
+	}
+	return false
+}
+
+//dispatch general request
+//used as cb for general request
+func (f *Router) DispatchGen(in *pb.GateReq) *pb.GateResp {
+	return f.DispatchGenCtx(context.Background(), in)
+}
+
+//dispatch general request with context
+//used as context cb for general request
+func (f *Router) DispatchGenCtx(ctx context.Context, in *pb.GateReq) *pb.GateResp {
+	//basic check
+	if in == nil {
+		return nil
+	}
+
+	//get sub router or handler
+	f.RLock()
+	sub := f.subMap[in.Service]
+	handler := f.getGenHandler(in.MessageId)
+	notFound := f.cbForGenNotFound
+	f.RUnlock()
+
+	//dispatch to sub router
+	if sub != nil {
+		return sub.DispatchGenCtx(ctx, in)
+	}
+
+	//call handler
+	if handler != nil {
+		return handler(ctx, in)
+	}
+	if notFound != nil {
+		return notFound(in)
+	}
+	return &pb.GateResp{
+		Service:in.Service,
+		MessageId:in.MessageId,
+		ErrorCode:define.NotFoundErrCode,
+		ErrorMessage:fmt.Sprintf("no handler for message %d", in.MessageId),
+	}
+}
+
+//register stream handler by message id
+func (f *Router) HandleStream(
+				messageId uint32,
+				handler func(remoteAddr string, in *pb.ByteMessage) bool,
+			) bool {
+	if handler == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.streamMap[messageId] = handler
+	return true
+}
+
+//register stream handler by message id range, include begin and end
+func (f *Router) HandleStreamRange(
+				begin, end uint32,
+				handler func(remoteAddr string, in *pb.ByteMessage) bool,
+			) bool {
+	if begin > end || handler == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.streamRanges = append(f.streamRanges, streamRange{
+		begin:begin,
+		end:end,
+		handler:handler,
+	})
+	return true
+}
+
+//register general handler by message id
+func (f *Router) HandleGen(
+				messageId uint32,
+				handler func(in *pb.GateReq) *pb.GateResp,
+			) bool {
+	if handler == nil {
+		return false
+	}
+	return f.HandleGenCtx(messageId, func(ctx context.Context, in *pb.GateReq) *pb.GateResp {
+		return handler(in)
+	})
+}
+
+//register general handler with context by message id
+func (f *Router) HandleGenCtx(
+				messageId uint32,
+				handler func(ctx context.Context, in *pb.GateReq) *pb.GateResp,
+			) bool {
+	if handler == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.genMap[messageId] = handler
+	return true
+}
+
+//register general handler by message id range, include begin and end
+func (f *Router) HandleGenRange(
+				begin, end uint32,
+				handler func(in *pb.GateReq) *pb.GateResp,
+			) bool {
+	if begin > end || handler == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.genRanges = append(f.genRanges, genRange{
+		begin:begin,
+		end:end,
+		handler:func(ctx context.Context, in *pb.GateReq) *pb.GateResp {
+			return handler(in)
+		},
+	})
+	return true
+}
+
+//register stream handler with decoded value by message id
+//data decoded by codec registry, failed error passed to cb for codec error
+func (f *Router) HandleStreamValue(
+				messageId uint32,
+				handler func(remoteAddr string, in *pb.ByteMessage, v interface{}) bool,
+			) bool {
+	if handler == nil {
+		return false
+	}
+	return f.HandleStream(messageId, func(remoteAddr string, in *pb.ByteMessage) bool {
+		v, err := f.decode(in.MessageId, in.Data)
+		if err != nil {
+			f.RLock()
+			cb := f.cbForCodecError
+			f.RUnlock()
+			if cb != nil {
+				cb(remoteAddr, err)
+			}
+			return false
+		}
+		return handler(remoteAddr, in, v)
+	})
+}
+
+//register general handler with decoded value by message id
+//data decoded and returned value encoded by codec registry,
+//codec or handler error will be set into response
+func (f *Router) HandleGenValue(
+				messageId uint32,
+				handler func(in *pb.GateReq, v interface{}) (interface{}, error),
+			) bool {
+	if handler == nil {
+		return false
+	}
+	return f.HandleGen(messageId, func(in *pb.GateReq) *pb.GateResp {
+		//init response
+		resp := &pb.GateResp{
+			Service:in.Service,
+			MessageId:in.MessageId,
+		}
+
+		//decode request
+		v, err := f.decode(in.MessageId, in.Data)
+		if err != nil {
+			resp.ErrorCode = define.CodecErrCode
+			resp.ErrorMessage = err.Error()
+			return resp
+		}
+
+		//call handler
+		result, err := handler(in, v)
+		if err != nil {
+			resp.ErrorCode = define.HandlerErrCode
+			resp.ErrorMessage = err.Error()
+			return resp
+		}
+		if result == nil {
+			return resp
+		}
+
+		//encode result
+		resp.Data, err = f.encode(in.MessageId, result)
+		if err != nil {
+			resp.ErrorCode = define.CodecErrCode
+			resp.ErrorMessage = err.Error()
+		}
+		return resp
+	})
+}
+
+//set codec registry for value handler
+func (f *Router) SetCodecs(codecs iface.ICodecRegistry) bool {
+	if codecs == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.codecs = codecs
+	return true
+}
+
+//set cb for codec error of stream value handler
+func (f *Router) SetCBForCodecError(
+				cb func(remoteAddr string, err error) bool,
+			) bool {
+	if cb == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.cbForCodecError = cb
+	return true
+}
+
+//mount sub router by service kind
+//request of the kind will be dispatched to sub router
+func (f *Router) Mount(kind string, sub iface.IRouter) bool {
+	if kind == "" || sub == nil || sub == iface.IRouter(f) {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.subMap[kind] = sub
+	return true
+}
+
+//set cb for stream request without handler
+func (f *Router) SetCBForStreamNotFound(
+				cb func(remoteAddr string, in *pb.ByteMessage) bool,
+			) bool {
+	if cb == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.cbForStreamNotFound = cb
+	return true
+}
+
+//set cb for general request without handler
+func (f *Router) SetCBForGenNotFound(
+				cb func(in *pb.GateReq) *pb.GateResp,
+			) bool {
+	if cb == nil {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.cbForGenNotFound = cb
+	return true
+}
+
+////////////////
+//private func
+////////////////
+
+//decode data by codec registry
+func (f *Router) decode(messageId uint32, data []byte) (interface{}, error) {
+	f.RLock()
+	codecs := f.codecs
+	f.RUnlock()
+	if codecs == nil {
+		return nil, &define.CodecError{
+			Op:define.CodecOpOfDecode,
+			MessageId:messageId,
+			Err:define.ErrCodecNotFound,
+		}
+	}
+	return codecs.Decode(messageId, data)
+}
+
+//encode value by codec registry
+func (f *Router) encode(messageId uint32, v interface{}) ([]byte, error) {
+	f.RLock()
+	codecs := f.codecs
+	f.RUnlock()
+	if codecs == nil {
+		return nil, &define.CodecError{
+			Op:define.CodecOpOfEncode,
+			MessageId:messageId,
+			Err:define.ErrCodecNotFound,
+		}
+	}
+	return codecs.Encode(messageId, v)
+}
+
+//get stream handler by message id, without locker
+func (f *Router) getStreamHandler(
+				messageId uint32,
+			) func(remoteAddr string, in *pb.ByteMessage) bool {
+	handler, ok := f.streamMap[messageId]
+	if ok {
+		return handler
+	}
+	for _, v := range f.streamRanges {
+		if messageId >= v.begin && messageId <= v.end {
+			return v.handler
+		}
+	}
+	return nil
+}
+
+//get general handler by message id, without locker
+func (f *Router) getGenHandler(
+				messageId uint32,
+			) func(ctx context.Context, in *pb.GateReq) *pb.GateResp {
+	handler, ok := f.genMap[messageId]
+	if ok {
+		return handler
+	}
+	for _, v := range f.genRanges {
+		if messageId >= v.begin && messageId <= v.end {
+			return v.handler
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/andyzhou/tinygate/define"
+	pb "github.com/andyzhou/tinygate/proto"
+)
+
+//message of value handler
+type testEcho struct {
+	Text string `json:"text"`
+}
+
+//gen handler reply with fixed data
+func genReply(data string) func(in *pb.GateReq) *pb.GateResp {
+	return func(in *pb.GateReq) *pb.GateResp {
+		return &pb.GateResp{
+			MessageId:in.MessageId,
+			Data:[]byte(data),
+		}
+	}
+}
+
+func TestRouterDispatchGen(t *testing.T) {
+	router := NewRouter()
+	router.HandleGen(10, genReply("exact"))
+	router.HandleGenRange(5, 20, genReply("range1"))
+	router.HandleGenRange(1, 30, genReply("range2"))
+	if router.HandleGenRange(9, 8, genReply("bad")) || router.HandleGen(1, nil) {
+		t.Fatal("invalid handler should be rejected")
+	}
+
+	//exact first, then ranges in added order
+	cases := map[uint32]string{
+		10:"exact",
+		5:"range1",
+		20:"range1",
+		4:"range2",
+		30:"range2",
+	}
+	for messageId, want := range cases {
+		resp := router.DispatchGen(&pb.GateReq{MessageId:messageId})
+		if resp == nil || string(resp.Data) != want {
+			t.Fatalf("message %d got %v, want %s", messageId, resp, want)
+		}
+	}
+
+	//not found
+	resp := router.DispatchGen(&pb.GateReq{MessageId:31})
+	if resp == nil || resp.ErrorCode != define.NotFoundErrCode || resp.MessageId != 31 {
+		t.Fatalf("unmatched message got %v, want not found error code", resp)
+	}
+	router.SetCBForGenNotFound(genReply("not found"))
+	resp = router.DispatchGen(&pb.GateReq{MessageId:31})
+	if resp == nil || string(resp.Data) != "not found" {
+		t.Fatalf("not found cb got %v", resp)
+	}
+	if router.DispatchGen(nil) != nil {
+		t.Fatal("nil request should get nil")
+	}
+}
+
+func TestRouterDispatchStream(t *testing.T) {
+	var hit string
+	handler := func(name string) func(remoteAddr string, in *pb.ByteMessage) bool {
+		return func(remoteAddr string, in *pb.ByteMessage) bool {
+			hit = name
+			return true
+		}
+	}
+	router := NewRouter()
+	router.HandleStream(10, handler("exact"))
+	router.HandleStreamRange(1, 20, handler("range"))
+
+	for messageId, want := range map[uint32]string{10:"exact", 11:"range"} {
+		hit = ""
+		if !router.DispatchStream("a:1", &pb.ByteMessage{MessageId:messageId}) || hit != want {
+			t.Fatalf("message %d hit %q, want %s", messageId, hit, want)
+		}
+	}
+
+	//not found
+	hit = ""
+	if router.DispatchStream("a:1", &pb.ByteMessage{MessageId:21}) || hit != "" {
+		t.Fatal("unmatched message should not be handled")
+	}
+	router.SetCBForStreamNotFound(handler("not found"))
+	if !router.DispatchStream("a:1", &pb.ByteMessage{MessageId:21}) || hit != "not found" {
+		t.Fatalf("not found cb hit %q", hit)
+	}
+}
+
+func TestRouterMount(t *testing.T) {
+	router := NewRouter()
+	sub := NewRouter()
+	router.HandleGen(1, genReply("root"))
+	sub.HandleGen(1, genReply("sub"))
+	if router.Mount("", sub) || router.Mount("chat", router) {
+		t.Fatal("invalid mount should be rejected")
+	}
+	router.Mount("chat", sub)
+
+	resp := router.DispatchGen(&pb.GateReq{Service:"chat", MessageId:1})
+	if resp == nil || string(resp.Data) != "sub" {
+		t.Fatalf("mounted kind got %v, want sub", resp)
+	}
+	resp = router.DispatchGen(&pb.GateReq{Service:"room", MessageId:1})
+	if resp == nil || string(resp.Data) != "root" {
+		t.Fatalf("other kind got %v, want root", resp)
+	}
+}
+
+func TestRouterGenValue(t *testing.T) {
+	router := NewRouter()
+	router.HandleGenValue(1, func(in *pb.GateReq, v interface{}) (interface{}, error) {
+		req := v.(*testEcho)
+		if req.Text == "" {
+			return nil, errors.New("empty text")
+		}
+		return &testEcho{Text:"re:" + req.Text}, nil
+	})
+
+	//no codec registry
+	resp := router.DispatchGen(&pb.GateReq{MessageId:1, Data:[]byte(`{"text":"hi"}`)})
+	if resp.ErrorCode != define.CodecErrCode {
+		t.Fatalf("without codecs error code = %d, want %d", resp.ErrorCode, define.CodecErrCode)
+	}
+
+	//decode, call and encode
+	codecs := NewCodecRegistry()
+	codecs.Register(1, &testEcho{}, NewJsonCodec())
+	router.SetCodecs(codecs)
+	resp = router.DispatchGen(&pb.GateReq{MessageId:1, Data:[]byte(`{"text":"hi"}`)})
+	if resp.ErrorCode != 0 || string(resp.Data) != `{"text":"re:hi"}` {
+		t.Fatalf("got code %d data %s", resp.ErrorCode, resp.Data)
+	}
+
+	//handler error
+	resp = router.DispatchGen(&pb.GateReq{MessageId:1, Data:[]byte(`{}`)})
+	if resp.ErrorCode != define.HandlerErrCode || resp.ErrorMessage != "empty text" {
+		t.Fatalf("handler error got code %d message %q", resp.ErrorCode, resp.ErrorMessage)
+	}
+
+	//broken data
+	resp = router.DispatchGen(&pb.GateReq{MessageId:1, Data:[]byte(`{broken`)})
+	if resp.ErrorCode != define.CodecErrCode {
+		t.Fatalf("broken data error code = %d, want %d", resp.ErrorCode, define.CodecErrCode)
+	}
+}
+
+func TestRouterStreamValue(t *testing.T) {
+	var (
+		got string
+		codecErr error
+	)
+	router := NewRouter()
+	codecs := NewCodecRegistry()
+	codecs.Register(2, &testEcho{}, NewJsonCodec())
+	router.SetCodecs(codecs)
+	router.SetCBForCodecError(func(remoteAddr string, err error) bool {
+		codecErr = err
+		return true
+	})
+	router.HandleStreamValue(2, func(remoteAddr string, in *pb.ByteMessage, v interface{}) bool {
+		got = v.(*testEcho).Text
+		return true
+	})
+
+	if !router.DispatchStream("a:1", &pb.ByteMessage{MessageId:2, Data:[]byte(`{"text":"hi"}`)}) || got != "hi" {
+		t.Fatalf("stream value got %q, want hi", got)
+	}
+
+	//codec error passed to cb
+	if router.DispatchStream("a:1", &pb.ByteMessage{MessageId:2, Data:[]byte(`{broken`)}) {
+		t.Fatal("broken data should not be handled")
+	}
+	var target *define.CodecError
+	if !errors.As(codecErr, &target) || target.MessageId != 2 {
+		t.Fatalf("codec error cb got %v", codecErr)
+	}
+}
+
+func TestRouterDispatchGenCtx(t *testing.T) {
+	type ctxKey struct{}
+	router := NewRouter()
+	sub := NewRouter()
+	sub.HandleGenCtx(1, func(ctx context.Context, in *pb.GateReq) *pb.GateResp {
+		value, _ := ctx.Value(ctxKey{}).(string)
+		return &pb.GateResp{MessageId:in.MessageId, Data:[]byte(value)}
+	})
+	router.Mount("chat", sub)
+
+	//context passed to mounted router
+	ctx := context.WithValue(context.Background(), ctxKey{}, "from request")
+	resp := router.DispatchGenCtx(ctx, &pb.GateReq{Service:"chat", MessageId:1})
+	if resp == nil || string(resp.Data) != "from request" {
+		t.Fatalf("ctx handler got %v, want value of request ctx", resp)
+	}
+}
@@ -1,9 +1,13 @@
 package face
 
 import (
+	"context"
 	"github.com/andyzhou/tinygate/define"
 	pb "github.com/andyzhou/tinygate/proto"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 /*
@@ -19,7 +23,17 @@ import (
 	 remoteAddr string //client node remote address
 	 stream *pb.GateService_BindStreamServer //stream server from client node
 	 clientRespChan chan pb.ByteMessage //chan for send client response
+	 queue *QueueGuard //overflow policy of response queue
 	 closeChan chan bool
+	 closeOnce sync.Once //quit by node down or node quit, only once
+	 doneChan chan struct{} //closed when main process exit
+	 lastActive int64 //last active time of client node, unix nano seconds
+	 inSend int32 //response taken from queue but not sent yet
+	 app string //authenticated app of client node
+	 identity string //peer certificate identity of client node
+	 kind string //service kind of client node, from node up
+	 tag string //node tag of client node, from node up
+	 sync.RWMutex
  }
  
  //construct
@@ -32,7 +46,10 @@ func NewService(
 		remoteAddr:remoteAddr,
 		stream:stream,
 		clientRespChan:make(chan pb.ByteMessage, define.ResponseChanSize),
+		queue:NewQueueGuard(),
 		closeChan:make(chan bool, 1),
+		doneChan:make(chan struct{}),
+		lastActive:time.Now().UnixNano(),
 	}
 
 	//spawn main process
@@ -53,7 +70,15 @@ func (f *Service) Quit() {
 	}()
 
 	//send to chan
-	f.closeChan <- true
+	f.closeOnce.Do(func() {
+		f.closeChan <- true
+	})
+}
+
+//get done chan, closed after quit
+//bind stream of client node should be closed when done
+func (f *Service) Done() <-chan struct{} {
+	return f.doneChan
 }
 
  //send resp to client node
@@ -74,12 +99,41 @@ func (f *Service) SendClientResp(resp *pb.ByteMessage) (bRet bool) {
 		}
 	}()
 
-	//send to chan
-	f.clientRespChan <- *resp
-	bRet = true
+	//send to chan by overflow policy
+	err := f.queue.Push(nil, f.clientRespChan, resp)
+	bRet = err == nil
 	return
 }
 
+//send resp to client node with context
+//wait for queue room until context done,
+//if context without deadline, use default timeout
+func (f *Service) SendClientRespCtx(
+				ctx context.Context,
+				resp *pb.ByteMessage,
+			) (err error) {
+	//basic check
+	if ctx == nil || resp == nil {
+		return define.ErrInvalidParameter
+	}
+
+	//try catch panic, response chan closed
+	defer func() {
+		if subErr := recover(); subErr != nil {
+			log.Println("Service::SendClientRespCtx panic, err:", subErr)
+			err = define.ErrNoClientNode
+		}
+	}()
+
+	//send to chan by overflow policy
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Second * define.GateReqTimeout)
+		defer cancel()
+	}
+	return f.queue.Push(ctx, f.clientRespChan, resp)
+}
+
 //get remote client address
 func (f *Service) GetRemoteAddr() string {
 	return f.remoteAddr
@@ -89,6 +143,98 @@ func (f *Service) GetStream() *pb.GateService_BindStreamServer {
 	return f.stream
 }
 
+//set authenticated app of client node
+func (f *Service) SetApp(app string) {
+	f.Lock()
+	defer f.Unlock()
+	f.app = app
+}
+
+//get authenticated app of client node
+func (f *Service) GetApp() string {
+	f.RLock()
+	defer f.RUnlock()
+	return f.app
+}
+
+//set peer certificate identity of client node
+func (f *Service) SetIdentity(identity string) {
+	f.Lock()
+	defer f.Unlock()
+	f.identity = identity
+}
+
+//get peer certificate identity of client node
+func (f *Service) GetIdentity() string {
+	f.RLock()
+	defer f.RUnlock()
+	return f.identity
+}
+
+//get length of queued response
+func (f *Service) GetQueueLen() int {
+	return len(f.clientRespChan)
+}
+
+//get length of queued and sending response, used for drain
+func (f *Service) GetPendingLen() int {
+	return int(atomic.LoadInt32(&f.inSend)) + len(f.clientRespChan)
+}
+
+//set overflow policy of response queue
+func (f *Service) SetQueueOption(option *define.QueueOption) bool {
+	return f.queue.SetOption(option)
+}
+
+//get stat of response queue
+func (f *Service) GetQueueStat() define.QueueStat {
+	return f.queue.GetStat(f.clientRespChan)
+}
+
+//set cb for response queue crossing high water mark
+func (f *Service) SetCBForQueueHighWater(
+				cb func(remoteAddr string, depth int, over bool) bool,
+			) bool {
+	if cb == nil {
+		return false
+	}
+	return f.queue.SetCBForHighWater(func(depth int, over bool) {
+		cb(f.remoteAddr, depth, over)
+	})
+}
+
+//set kind and tag of client node
+func (f *Service) SetNode(kind, tag string) {
+	f.Lock()
+	defer f.Unlock()
+	f.kind = kind
+	f.tag = tag
+}
+
+//get kind of client node
+func (f *Service) GetKind() string {
+	f.RLock()
+	defer f.RUnlock()
+	return f.kind
+}
+
+//get tag of client node
+func (f *Service) GetTag() string {
+	f.RLock()
+	defer f.RUnlock()
+	return f.tag
+}
+
+//update active time of client node
+func (f *Service) UpdateActive() {
+	atomic.StoreInt64(&f.lastActive, time.Now().UnixNano())
+}
+
+//get last active time of client node
+func (f *Service) GetLastActive() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&f.lastActive))
+}
+
 ////////////////
 //private func
 ////////////////
@@ -108,6 +254,7 @@ func (f *Service) runMainProcess() {
 		}
 		close(f.clientRespChan)
 		close(f.closeChan)
+		close(f.doneChan)
 	}()
 
 	//loop
@@ -119,11 +266,15 @@ func (f *Service) runMainProcess() {
 		case resp, isOk = <- f.clientRespChan:
 			if isOk && f.stream != nil {
 				//cast to client node pass stream mode
+				//count in send, response left queue but not sent
+				atomic.AddInt32(&f.inSend, 1)
 				err = (*f.stream).Send(&resp)
+				atomic.AddInt32(&f.inSend, -1)
 				if err != nil {
 					log.Println("Service::runMainProcess send failed, err:",
 								err.Error())
 				}
+				f.queue.Check(len(f.clientRespChan))
 			}
 		case <- f.closeChan:
 			needQuit = true
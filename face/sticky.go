@@ -0,0 +1,122 @@
+package face
+
+import (
+	sysJson "encoding/json"
+	"github.com/andyzhou/tinygate/json"
+	"sync"
+)
+
+/*
+ * sticky table face, implement of IStickyTable
+ * - in memory assignment table for persistent routing rule
+ * - key -> gate address by service kind
+ * - support json snapshot for restart
+ */
+
+//face info
+type StickyTable struct {
+	kindMap map[string]map[string]string //kind -> key -> address
+	json.BaseJson
+	sync.RWMutex
+}
+
+//construct
+func NewStickyTable() *StickyTable {
+	//self init
+	this := &StickyTable{
+		kindMap:make(map[string]map[string]string),
+	}
+	return this
+}
+
+//////////////////////
+//implement of IStickyTable
+//////////////////////
+
+//get assigned address
+func (f *StickyTable) Get(kind, key string) string {
+	f.RLock()
+	defer f.RUnlock()
+	keyMap, ok := f.kindMap[kind]
+	if !ok {
+		return ""
+	}
+	return keyMap[key]
+}
+
+//set assigned address
+func (f *StickyTable) Set(kind, key, address string) {
+	if kind == "" || key == "" || address == "" {
+		return
+	}
+	f.Lock()
+	defer f.Unlock()
+	keyMap, ok := f.kindMap[kind]
+	if !ok {
+		keyMap = make(map[string]string)
+		f.kindMap[kind] = keyMap
+	}
+	keyMap[key] = address
+}
+
+//remove key of one kind
+func (f *StickyTable) Remove(kind, key string) {
+	f.Lock()
+	defer f.Unlock()
+	keyMap, ok := f.kindMap[kind]
+	if !ok {
+		return
+	}
+	delete(keyMap, key)
+}
+
+//remove key of all kinds
+func (f *StickyTable) RemoveKey(key string) {
+	f.Lock()
+	defer f.Unlock()
+	for _, keyMap := range f.kindMap {
+		delete(keyMap, key)
+	}
+}
+
+//get keys assigned to address
+func (f *StickyTable) GetKeys(kind, address string) []string {
+	result := make([]string, 0)
+	f.RLock()
+	defer f.RUnlock()
+	keyMap, ok := f.kindMap[kind]
+	if !ok {
+		return result
+	}
+	for key, v := range keyMap {
+		if v == address {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+//get json snapshot
+func (f *StickyTable) Snapshot() []byte {
+	f.RLock()
+	defer f.RUnlock()
+	return f.BaseJson.Encode(f.kindMap)
+}
+
+//restore from json snapshot
+//snapshot may be broken, decode silently and return error
+func (f *StickyTable) Restore(data []byte) error {
+	kindMap := make(map[string]map[string]string)
+	if err := sysJson.Unmarshal(data, &kindMap); err != nil {
+		return err
+	}
+	for kind, keyMap := range kindMap {
+		if keyMap == nil {
+			delete(kindMap, kind)
+		}
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.kindMap = kindMap
+	return nil
+}
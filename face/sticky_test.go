@@ -0,0 +1,83 @@
+package face
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestStickyTableSetAndRemove(t *testing.T) {
+	table := NewStickyTable()
+	table.Set("chat", "k1", "a:1")
+	table.Set("chat", "k2", "a:1")
+	table.Set("chat", "k3", "a:2")
+	table.Set("room", "k1", "b:1")
+
+	//invalid para will be ignored
+	table.Set("", "k4", "a:1")
+	table.Set("chat", "", "a:1")
+	table.Set("chat", "k4", "")
+
+	if got := table.Get("chat", "k1"); got != "a:1" {
+		t.Fatalf("get chat/k1 = %q, want a:1", got)
+	}
+	if got := table.Get("chat", "k4"); got != "" {
+		t.Fatalf("get chat/k4 = %q, want empty", got)
+	}
+	keys := table.GetKeys("chat", "a:1")
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "k1" || keys[1] != "k2" {
+		t.Fatalf("keys of a:1 = %v, want [k1 k2]", keys)
+	}
+
+	//remove one kind
+	table.Remove("chat", "k2")
+	if got := table.Get("chat", "k2"); got != "" {
+		t.Fatalf("chat/k2 not removed, got %q", got)
+	}
+
+	//remove all kinds
+	table.RemoveKey("k1")
+	if table.Get("chat", "k1") != "" || table.Get("room", "k1") != "" {
+		t.Fatal("k1 not removed from all kinds")
+	}
+	if got := table.Get("chat", "k3"); got != "a:2" {
+		t.Fatalf("get chat/k3 = %q, want a:2", got)
+	}
+}
+
+func TestStickyTableSnapshot(t *testing.T) {
+	table := NewStickyTable()
+	table.Set("chat", "k1", "a:1")
+	table.Set("room", "k2", "b:1")
+	data := table.Snapshot()
+
+	restored := NewStickyTable()
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("restore failed, err:%v", err)
+	}
+	if restored.Get("chat", "k1") != "a:1" || restored.Get("room", "k2") != "b:1" {
+		t.Fatalf("restored table mismatch, snapshot:%s", data)
+	}
+
+	//null kind map should be dropped, so set still works
+	if err := restored.Restore([]byte(`{"chat":null}`)); err != nil {
+		t.Fatalf("restore null kind failed, err:%v", err)
+	}
+	restored.Set("chat", "k1", "a:2")
+	if got := restored.Get("chat", "k1"); got != "a:2" {
+		t.Fatalf("get chat/k1 = %q, want a:2", got)
+	}
+}
+
+func TestStickyTableRestoreInvalid(t *testing.T) {
+	table := NewStickyTable()
+	table.Set("chat", "k1", "a:1")
+	if err := table.Restore([]byte("{broken")); err == nil {
+		t.Fatal("restore broken snapshot should fail")
+	}
+
+	//table untouched on failure
+	if got := table.Get("chat", "k1"); got != "a:1" {
+		t.Fatalf("get chat/k1 = %q after failed restore, want a:1", got)
+	}
+}
@@ -0,0 +1,258 @@
+package face
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/andyzhou/tinygate/define"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+/*
+ * tls face
+ * - tls and mutual tls option for gate client and server
+ * - load certificate and ca pool from disk
+ * - hot reload when files changed, no need restart
+ */
+
+//tls loader info
+type TLSLoader struct {
+	option define.TLSOption
+	cert *tls.Certificate
+	caPool *x509.CertPool
+	modTimeMap map[string]time.Time //file -> last modify time
+	closeChan chan bool
+	sync.RWMutex
+}
+
+//construct
+func NewTLSLoader(option *define.TLSOption) (*TLSLoader, error) {
+	//basic check
+	if option == nil {
+		return nil, errors.New("invalid parameter")
+	}
+	if (option.CertFile == "") != (option.KeyFile == "") {
+		return nil, errors.New("cert file and key file should be set together")
+	}
+	if option.RequireClientCert && option.CAFile == "" {
+		//nil client ca pool means trust system roots
+		return nil, errors.New("ca file is required for client cert verify")
+	}
+
+	//self init
+	this := &TLSLoader{
+		option:*option,
+		modTimeMap:make(map[string]time.Time),
+		closeChan:make(chan bool, 1),
+	}
+
+	//load files
+	err := this.load()
+	if err != nil {
+		return nil, err
+	}
+
+	//spawn reload process
+	go this.runReloadProcess()
+	return this, nil
+}
+
+//construct for server side
+//certificate and private key are required
+func NewServerTLSLoader(option *define.TLSOption) (*TLSLoader, error) {
+	//basic check
+	if option == nil {
+		return nil, errors.New("invalid parameter")
+	}
+	if option.CertFile == "" || option.KeyFile == "" {
+		return nil, errors.New("cert file and key file are required for server")
+	}
+	return NewTLSLoader(option)
+}
+
+//quit
+func (f *TLSLoader) Quit() {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("TLSLoader:Quit panic, err:", err)
+		}
+	}()
+	f.closeChan <- true
+}
+
+//get server side tls config
+func (f *TLSLoader) GetServerConfig() *tls.Config {
+	config := &tls.Config{
+		GetConfigForClient:func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			//build config with current certificate and ca pool
+			f.RLock()
+			defer f.RUnlock()
+			current := &tls.Config{
+				ClientCAs:f.caPool,
+				ClientAuth:tls.NoClientCert,
+			}
+			if f.cert != nil {
+				current.Certificates = []tls.Certificate{*f.cert}
+			}
+			if f.option.RequireClientCert {
+				current.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return current, nil
+		},
+	}
+	return config
+}
+
+//get client side tls config
+func (f *TLSLoader) GetClientConfig() *tls.Config {
+	config := &tls.Config{
+		ServerName:f.option.ServerName,
+		GetClientCertificate:func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			f.RLock()
+			defer f.RUnlock()
+			if f.cert == nil {
+				return &tls.Certificate{}, nil
+			}
+			return f.cert, nil
+		},
+	}
+
+	//verify server with current ca pool
+	if f.option.CAFile != "" {
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = f.verifyServer
+	}
+	return config
+}
+
+////////////////
+//private func
+////////////////
+
+//verify server certificate with current ca pool
+func (f *TLSLoader) verifyServer(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) <= 0 {
+		return errors.New("no server certificate")
+	}
+	f.RLock()
+	caPool := f.caPool
+	f.RUnlock()
+
+	//init verify option
+	opts := x509.VerifyOptions{
+		Roots:caPool,
+		DNSName:state.ServerName,
+		Intermediates:x509.NewCertPool(),
+	}
+	if f.option.ServerName != "" {
+		opts.DNSName = f.option.ServerName
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := state.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+//load certificate and ca pool
+func (f *TLSLoader) load() error {
+	var (
+		cert *tls.Certificate
+		caPool *x509.CertPool
+	)
+
+	//load certificate
+	if f.option.CertFile != "" && f.option.KeyFile != "" {
+		pair, err := tls.LoadX509KeyPair(f.option.CertFile, f.option.KeyFile)
+		if err != nil {
+			return err
+		}
+		cert = &pair
+	}
+
+	//load ca pool
+	if f.option.CAFile != "" {
+		data, err := os.ReadFile(f.option.CAFile)
+		if err != nil {
+			return err
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(data) {
+			return errors.New("invalid ca file")
+		}
+	}
+
+	//sync with locker
+	f.Lock()
+	defer f.Unlock()
+	f.cert = cert
+	f.caPool = caPool
+	for _, file := range f.getFiles() {
+		stat, err := os.Stat(file)
+		if err == nil {
+			f.modTimeMap[file] = stat.ModTime()
+		}
+	}
+	return nil
+}
+
+//check files changed or not
+func (f *TLSLoader) isChanged() bool {
+	f.RLock()
+	defer f.RUnlock()
+	for _, file := range f.getFiles() {
+		stat, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !stat.ModTime().Equal(f.modTimeMap[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+//get files of option
+func (f *TLSLoader) getFiles() []string {
+	files := make([]string, 0)
+	for _, file := range []string{f.option.CertFile, f.option.KeyFile, f.option.CAFile} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+//run reload process
+func (f *TLSLoader) runReloadProcess() {
+	var (
+		ticker = time.NewTicker(time.Second * define.TLSReloadRate)
+	)
+
+	//defer
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("TLSLoader:runReloadProcess panic, err:", err)
+		}
+		ticker.Stop()
+		close(f.closeChan)
+	}()
+
+	//loop
+	for {
+		select {
+		case <- ticker.C:
+			if f.isChanged() {
+				err := f.load()
+				if err != nil {
+					log.Println("TLSLoader::runReloadProcess, reload failed, err:", err.Error())
+				}
+			}
+		case <- f.closeChan:
+			return
+		}
+	}
+}
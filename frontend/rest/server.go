@@ -0,0 +1,217 @@
+package rest
+
+import (
+	"errors"
+	"github.com/andyzhou/tinygate"
+	"github.com/andyzhou/tinygate/define"
+	pb "github.com/andyzhou/tinygate/proto"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+ * http/json front end server
+ *
+ * - map `POST /{service}/{messageId}` to general request
+ * - auth headers fill `GateReq.Auth`
+ * - query para `address` fill `GateReq.Address` for pinned routing
+ * - map `GateResp` error code and message to http status and headers
+ * - map send error to 503(no gate), 504(timeout) or 502(others)
+ * - body over `HttpMaxBodySize` is rejected with 413
+ * - can be used as http.Handler or standalone listener
+ */
+
+//server info
+type Server struct {
+	address string //listen address, host:port
+	prefix string //url path prefix, optional
+	client *tinygate.Client //gate client
+	httpServer *http.Server
+	cbForStatus func(errorCode int32) int //cb for map error code to http status
+}
+
+//construct
+func NewServer(
+			address string,
+			client *tinygate.Client,
+		) *Server {
+	//self init
+	this := &Server{
+		address:address,
+		client:client,
+	}
+	return this
+}
+
+//quit
+func (s *Server) Quit() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+//start standalone listener
+func (s *Server) Start() error {
+	//basic check
+	if s.address == "" || s.client == nil {
+		return errors.New("invalid parameter")
+	}
+
+	//init http server
+	s.httpServer = &http.Server{
+		Addr:s.address,
+		Handler:s,
+	}
+
+	//listen first, return error directly
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	//spawn http service
+	go func() {
+		err := s.httpServer.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Println("Server::Start, listen failed, err:", err.Error())
+		}
+	}()
+	return nil
+}
+
+//implement of http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	//only support post method
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	//parse service kind and message id from path
+	kind, messageId, err := s.parsePath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	//read request body, reject too large body
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, define.HttpMaxBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//init general request
+	in := &pb.GateReq{
+		Service:kind,
+		MessageId:messageId,
+		Data:data,
+		Address:r.URL.Query().Get(define.HttpParaOfAddress),
+	}
+	app := r.Header.Get(define.HttpHeaderOfApp)
+	token := r.Header.Get(define.HttpHeaderOfToken)
+	if app != "" || token != "" {
+		in.Auth = &pb.AccessAuth{
+			App:app,
+			Token:token,
+		}
+	}
+
+	//send general request to sub service
+	//cancel it when end user gone
+	resp, err := s.client.SendGenReqCtx(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), s.getErrStatus(err))
+		return
+	}
+	if resp == nil {
+		http.Error(w, "no response from sub service", http.StatusBadGateway)
+		return
+	}
+
+	//write response
+	w.Header().Set("Content-Type", "application/json")
+	if resp.ErrorCode != 0 || resp.ErrorMessage != "" {
+		w.Header().Set(define.HttpHeaderOfErrorCode, strconv.Itoa(int(resp.ErrorCode)))
+		w.Header().Set(define.HttpHeaderOfErrorMessage, resp.ErrorMessage)
+	}
+	w.WriteHeader(s.getStatus(resp.ErrorCode))
+	w.Write(resp.Data)
+}
+
+//set url path prefix, like `/api`
+func (s *Server) SetPrefix(prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+	s.prefix = strings.TrimRight(prefix, "/")
+	return true
+}
+
+//set cb for map error code to http status
+//if not set, zero is 200, others are 400
+func (s *Server) SetCBForStatus(cb func(errorCode int32) int) bool {
+	if cb == nil {
+		return false
+	}
+	s.cbForStatus = cb
+	return true
+}
+
+////////////////
+//private func
+///////////////
+
+//parse service kind and message id from url path
+func (s *Server) parsePath(path string) (string, uint32, error) {
+	//remove prefix
+	if s.prefix != "" {
+		if !strings.HasPrefix(path, s.prefix + "/") {
+			return "", 0, errors.New("invalid path")
+		}
+		path = strings.TrimPrefix(path, s.prefix)
+	}
+
+	//split `/{service}/{messageId}`
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		return "", 0, errors.New("invalid path")
+	}
+	messageId, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return "", 0, errors.New("invalid message id")
+	}
+	return parts[0], uint32(messageId), nil
+}
+
+//get http status by send error
+func (s *Server) getErrStatus(err error) int {
+	switch {
+	case errors.Is(err, define.ErrNoGate), errors.Is(err, define.ErrGateDown):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, define.ErrTimeout):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+//get http status by error code
+func (s *Server) getStatus(errorCode int32) int {
+	if s.cbForStatus != nil {
+		return s.cbForStatus(errorCode)
+	}
+	if errorCode == 0 {
+		return http.StatusOK
+	}
+	return http.StatusBadRequest
+}
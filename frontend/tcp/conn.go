@@ -0,0 +1,157 @@
+package tcp
+
+import (
+	"bufio"
+	"errors"
+	"github.com/andyzhou/tinygate/define"
+	pb "github.com/andyzhou/tinygate/proto"
+	"log"
+	"net"
+	"sync"
+)
+
+/*
+ * tcp connect face
+ *
+ * - one end user socket one conn instance
+ * - read frames from socket, forward to server
+ * - write frames to socket in async mode
+ */
+
+//conn info
+type Conn struct {
+	connId uint32
+	conn net.Conn
+	server *Server
+	writeChan chan *Packet
+	closeChan chan bool
+	closeOnce sync.Once
+}
+
+//construct
+func NewConn(
+			connId uint32,
+			conn net.Conn,
+			server *Server,
+		) *Conn {
+	//self init
+	this := &Conn{
+		connId:connId,
+		conn:conn,
+		server:server,
+		writeChan:make(chan *Packet, define.ConnWriteChanSize),
+		closeChan:make(chan bool),
+	}
+	return this
+}
+
+//close
+func (c *Conn) Close() {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+		c.conn.Close()
+		c.server.removeConn(c.connId)
+	})
+}
+
+//get connect id
+func (c *Conn) GetConnId() uint32 {
+	return c.connId
+}
+
+//get remote address
+func (c *Conn) GetRemoteAddr() string {
+	return c.conn.RemoteAddr().String()
+}
+
+//write message to end user
+func (c *Conn) Write(in *pb.ByteMessage) error {
+	//basic check
+	if in == nil {
+		return errors.New("invalid parameter")
+	}
+
+	//init packet
+	packet := &Packet{
+		MessageId:in.MessageId,
+		Data:in.Data,
+	}
+
+	//send to chan without wait, called in gate receive process
+	//slow end user will be closed when write chan is full
+	select {
+	case <- c.closeChan:
+		return errors.New("connect closed")
+	case c.writeChan <- packet:
+	default:
+		log.Println("Conn::Write write chan is full, close connect:", c.connId)
+		c.Close()
+		return define.ErrQueueFull
+	}
+	return nil
+}
+
+///////////////
+//private func
+///////////////
+
+//spawn read and write process
+func (c *Conn) start() {
+	go c.runWriteProcess()
+	go c.runReadProcess()
+}
+
+//read frames from socket
+func (c *Conn) runReadProcess() {
+	var (
+		reader = bufio.NewReader(c.conn)
+	)
+
+	//defer
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Conn::runReadProcess panic, err:", err)
+		}
+		c.Close()
+	}()
+
+	//loop
+	for {
+		packet := NewPacket()
+		err := packet.Decode(reader)
+		if err != nil {
+			return
+		}
+		//forward to gate client
+		c.server.forward(c.connId, packet)
+	}
+}
+
+//write frames into socket
+func (c *Conn) runWriteProcess() {
+	var (
+		packet *Packet
+	)
+
+	//defer
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Conn::runWriteProcess panic, err:", err)
+		}
+	}()
+
+	//loop
+	for {
+		select {
+		case packet = <- c.writeChan:
+			_, err := c.conn.Write(packet.Encode())
+			if err != nil {
+				log.Println("Conn::runWriteProcess write failed, err:", err.Error())
+				c.Close()
+				return
+			}
+		case <- c.closeChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package tcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/andyzhou/tinygate/define"
+	"io"
+)
+
+/*
+ * tcp packet
+ *
+ * - length prefixed frame, big endian
+ * - header: body length(4) + message id(4)
+ * - body: raw message data
+ */
+
+//packet info
+type Packet struct {
+	MessageId uint32
+	Data []byte
+}
+
+//construct
+func NewPacket() *Packet {
+	this := &Packet{}
+	return this
+}
+
+//encode packet into frame
+func (p *Packet) Encode() []byte {
+	frame := make([]byte, define.TcpHeaderSize + len(p.Data))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(p.Data)))
+	binary.BigEndian.PutUint32(frame[4:8], p.MessageId)
+	copy(frame[define.TcpHeaderSize:], p.Data)
+	return frame
+}
+
+//decode one frame from reader
+func (p *Packet) Decode(reader io.Reader) error {
+	var (
+		header = make([]byte, define.TcpHeaderSize)
+	)
+
+	//read header
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		return err
+	}
+
+	//check body length
+	bodyLen := binary.BigEndian.Uint32(header[0:4])
+	if bodyLen > define.TcpMaxBodySize {
+		return errors.New("packet body too large")
+	}
+
+	//read body
+	body := make([]byte, bodyLen)
+	_, err = io.ReadFull(reader, body)
+	if err != nil {
+		return err
+	}
+
+	//sync packet
+	p.MessageId = binary.BigEndian.Uint32(header[4:8])
+	p.Data = body
+	return nil
+}
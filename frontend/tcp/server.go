@@ -0,0 +1,259 @@
+package tcp
+
+import (
+	"errors"
+	"github.com/andyzhou/tinygate"
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/iface"
+	pb "github.com/andyzhou/tinygate/proto"
+	"log"
+	"net"
+	"time"
+)
+
+/*
+ * tcp front end server
+ *
+ * - accept raw end user sockets
+ * - assign uint32 connect id for each socket
+ * - forward inbound frames to sub service pass gate client
+ * - fan out downstream messages to sockets by `ConnIds`
+ * - should be created before `AddGateServer` of the gate client,
+ *   so that the stream received cb can be set on all gates.
+ * - own the stream received cb of gate client, if it has been set,
+ *   `Dispatch` should be called in that cb manually.
+ */
+
+//server info
+type Server struct {
+	address string //listen address, host:port
+	kind string //default sub service kind
+	client *tinygate.Client //gate client
+	listener net.Listener
+	registry iface.IConnRegistry //shared connect registry of gate client
+	cbForKind func(connId, messageId uint32) string //cb for pick service kind
+	cbForConnClosed func(connId uint32) bool //cb for end user socket closed
+	closeChan chan bool
+}
+
+//construct
+func NewServer(
+			address, kind string,
+			client *tinygate.Client,
+		) *Server {
+	//self init
+	this := &Server{
+		address:address,
+		kind:kind,
+		client:client,
+		closeChan:make(chan bool),
+	}
+
+	//set cb for downstream data
+	//front end owns the stream received cb of gate client
+	if client != nil {
+		this.registry = client.GetConnRegistry()
+		if !client.SetCBForStreamReceived(this.Dispatch) {
+			log.Println("Server::NewServer, stream received cb has been set, " +
+						"call `Dispatch` in it manually")
+		}
+	}
+	return this
+}
+
+//quit
+func (s *Server) Quit() {
+	//try catch panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Server:Quit panic, err:", err)
+		}
+	}()
+
+	//stop accept
+	close(s.closeChan)
+	if s.listener != nil {
+		s.listener.Close()
+	}
+
+	//close all connects
+	for _, conn := range s.GetAllConn() {
+		conn.Close()
+	}
+}
+
+//start
+func (s *Server) Start() error {
+	//basic check
+	if s.address == "" || s.client == nil {
+		return errors.New("invalid parameter")
+	}
+
+	//try listen tcp port
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+
+	//spawn accept process
+	go s.runAcceptProcess()
+	return nil
+}
+
+//dispatch downstream message to front end sockets
+//can be called manually if stream received cb has been set outside
+func (s *Server) Dispatch(from string, in *pb.ByteMessage) bool {
+	if s.registry == nil {
+		return false
+	}
+	return s.registry.Cast(in)
+}
+
+//get connect by id
+func (s *Server) GetConn(connId uint32) *Conn {
+	if s.registry == nil {
+		return nil
+	}
+	meta := s.registry.Get(connId)
+	if meta == nil {
+		return nil
+	}
+	conn, ok := meta.GetConn().(*Conn)
+	if !ok {
+		return nil
+	}
+	return conn
+}
+
+//get all connects of current front end
+func (s *Server) GetAllConn() []*Conn {
+	result := make([]*Conn, 0)
+	if s.registry == nil {
+		return result
+	}
+	s.registry.Range(func(meta iface.IConnMeta) bool {
+		conn, ok := meta.GetConn().(*Conn)
+		if ok && conn.server == s {
+			result = append(result, conn)
+		}
+		return true
+	})
+	return result
+}
+
+//set cb for pick service kind by message id
+//if not set, use default kind
+func (s *Server) SetCBForKind(cb func(connId, messageId uint32) string) bool {
+	if cb == nil {
+		return false
+	}
+	s.cbForKind = cb
+	return true
+}
+
+//set cb for end user socket closed
+func (s *Server) SetCBForConnClosed(cb func(connId uint32) bool) bool {
+	if cb == nil {
+		return false
+	}
+	s.cbForConnClosed = cb
+	return true
+}
+
+////////////////
+//private func
+///////////////
+
+//forward inbound packet to sub service
+func (s *Server) forward(connId uint32, packet *Packet) bool {
+	//inter message id not allowed from end user
+	if packet.MessageId <= define.MessageIdOfInterMax {
+		return false
+	}
+
+	//pick service kind
+	kind := s.kind
+	if s.cbForKind != nil {
+		kind = s.cbForKind(connId, packet.MessageId)
+	}
+	if kind == "" {
+		return false
+	}
+
+	//update connect stat
+	meta := s.registry.Get(connId)
+	if meta != nil {
+		meta.AddBytesIn(len(packet.Data))
+	}
+
+	//init byte message
+	in := &pb.ByteMessage{
+		Service:kind,
+		MessageId:packet.MessageId,
+		Data:packet.Data,
+		ConnIds:[]uint32{connId},
+	}
+
+	//cast to sub service
+	return s.client.CastDataByKind(kind, in)
+}
+
+//remove closed connect
+func (s *Server) removeConn(connId uint32) {
+	ok := s.registry.Remove(connId)
+
+	//notify outside
+	if ok && s.cbForConnClosed != nil {
+		s.cbForConnClosed(connId)
+	}
+}
+
+//accept new sockets
+//back off on temporary error, exit on the others
+func (s *Server) runAcceptProcess() {
+	var (
+		delay time.Duration
+	)
+
+	//defer
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Server::runAcceptProcess panic, err:", err)
+		}
+	}()
+
+	//loop
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <- s.closeChan:
+				return
+			default:
+			}
+			netErr, ok := err.(net.Error)
+			if !ok || !netErr.Temporary() {
+				log.Println("Server::runAcceptProcess accept failed, exit, err:", err.Error())
+				return
+			}
+			if delay <= 0 {
+				delay = time.Millisecond * define.AcceptRetryMinDelay
+			}else{
+				delay *= 2
+			}
+			if delay > time.Millisecond * define.AcceptRetryMaxDelay {
+				delay = time.Millisecond * define.AcceptRetryMaxDelay
+			}
+			log.Println("Server::runAcceptProcess accept failed, retry in", delay, ", err:", err.Error())
+			time.Sleep(delay)
+			continue
+		}
+		delay = 0
+
+		//init new connect, register before start
+		newConn := NewConn(s.registry.NewConnId(), conn, s)
+		s.registry.Add(newConn)
+		newConn.start()
+	}
+}
@@ -0,0 +1,216 @@
+package ws
+
+import (
+	"errors"
+	"github.com/andyzhou/tinygate/define"
+	pb "github.com/andyzhou/tinygate/proto"
+	"github.com/gorilla/websocket"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+ * websocket connect face
+ *
+ * - one end user connect one conn instance
+ * - read binary or text frames, forward to server
+ * - write frames with buffered chan in async mode
+ * - keep alive with ping/pong
+ */
+
+//conn info
+type Conn struct {
+	connId uint32
+	conn *websocket.Conn
+	server *Server
+	frameType int32 //frame type of last inbound message, used for reply
+	writeChan chan *pb.ByteMessage
+	closeChan chan bool
+	closeOnce sync.Once
+}
+
+//construct
+func NewConn(
+			connId uint32,
+			conn *websocket.Conn,
+			server *Server,
+			writeChanSize int,
+		) *Conn {
+	//self init
+	this := &Conn{
+		connId:connId,
+		conn:conn,
+		server:server,
+		frameType:websocket.BinaryMessage,
+		writeChan:make(chan *pb.ByteMessage, writeChanSize),
+		closeChan:make(chan bool),
+	}
+	return this
+}
+
+//close
+func (c *Conn) Close() {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+		c.conn.Close()
+		c.server.removeConn(c.connId)
+	})
+}
+
+//get connect id
+func (c *Conn) GetConnId() uint32 {
+	return c.connId
+}
+
+//get remote address
+func (c *Conn) GetRemoteAddr() string {
+	return c.conn.RemoteAddr().String()
+}
+
+//write message to end user
+func (c *Conn) Write(in *pb.ByteMessage) error {
+	//basic check
+	if in == nil {
+		return errors.New("invalid parameter")
+	}
+
+	//send to chan
+	select {
+	case <- c.closeChan:
+		return errors.New("connect closed")
+	case c.writeChan <- in:
+	default:
+		//write chan is full, slow end user
+		log.Println("Conn::Write write chan is full, close connect:", c.connId)
+		c.Close()
+		return define.ErrQueueFull
+	}
+	return nil
+}
+
+///////////////
+//private func
+///////////////
+
+//spawn read and write process
+func (c *Conn) start() {
+	go c.runWriteProcess()
+	go c.runReadProcess()
+}
+
+//read frames from connect
+func (c *Conn) runReadProcess() {
+	var (
+		messageId uint32
+		data []byte
+	)
+
+	//defer
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Conn::runReadProcess panic, err:", err)
+		}
+		c.Close()
+	}()
+
+	//init read setting
+	pongWait := time.Second * define.WsPongWait
+	c.conn.SetReadLimit(define.WsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	//loop
+	for {
+		frameType, frame, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		//decode by frame type
+		switch frameType {
+		case websocket.BinaryMessage:
+			messageId, data, err = DecodeBinary(frame)
+			if err != nil {
+				continue
+			}
+		case websocket.TextMessage:
+			packet := NewPacket()
+			if err = packet.Decode(frame); err != nil {
+				continue
+			}
+			messageId = packet.MessageId
+			data = []byte(packet.Data)
+		default:
+			continue
+		}
+
+		//reply with the same frame type
+		atomic.StoreInt32(&c.frameType, int32(frameType))
+
+		//forward to gate client
+		c.server.forward(c.connId, messageId, data)
+	}
+}
+
+//write frames into connect
+func (c *Conn) runWriteProcess() {
+	var (
+		in *pb.ByteMessage
+		frame []byte
+		err error
+		ticker = time.NewTicker(time.Second * define.WsPingRate)
+		writeWait = time.Second * define.WsWriteWait
+	)
+
+	//defer
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Conn::runWriteProcess panic, err:", err)
+		}
+		ticker.Stop()
+	}()
+
+	//loop
+	for {
+		select {
+		case in = <- c.writeChan:
+			{
+				//encode by frame type
+				frameType := int(atomic.LoadInt32(&c.frameType))
+				if frameType == websocket.TextMessage {
+					packet := NewPacket()
+					packet.MessageId = in.MessageId
+					packet.Data = string(in.Data)
+					frame = packet.Encode()
+				}else{
+					frame = EncodeBinary(in.MessageId, in.Data)
+				}
+
+				//write frame
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				err = c.conn.WriteMessage(frameType, frame)
+				if err != nil {
+					log.Println("Conn::runWriteProcess write failed, err:", err.Error())
+					c.Close()
+					return
+				}
+			}
+		case <- ticker.C:
+			{
+				//keep alive
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				err = c.conn.WriteMessage(websocket.PingMessage, nil)
+				if err != nil {
+					c.Close()
+					return
+				}
+			}
+		case <- c.closeChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package ws
+
+import (
+	"encoding/binary"
+	sysJson "encoding/json"
+	"errors"
+	"github.com/andyzhou/tinygate/json"
+)
+
+/*
+ * websocket packet
+ *
+ * - binary frame: message id(4, big endian) + body
+ * - text frame: json, {"messageId":xx, "data":"xx"}
+ */
+
+//packet size
+const (
+	MessageIdSize = 4
+)
+
+//packet info
+type Packet struct {
+	MessageId uint32 `json:"messageId"`
+	Data string `json:"data"`
+	json.BaseJson
+}
+
+//construct
+func NewPacket() *Packet {
+	this := &Packet{}
+	return this
+}
+
+//encode binary frame
+func EncodeBinary(messageId uint32, data []byte) []byte {
+	frame := make([]byte, MessageIdSize + len(data))
+	binary.BigEndian.PutUint32(frame[0:MessageIdSize], messageId)
+	copy(frame[MessageIdSize:], data)
+	return frame
+}
+
+//decode binary frame
+func DecodeBinary(frame []byte) (uint32, []byte, error) {
+	if len(frame) < MessageIdSize {
+		return 0, nil, errors.New("invalid binary frame")
+	}
+	messageId := binary.BigEndian.Uint32(frame[0:MessageIdSize])
+	data := make([]byte, len(frame) - MessageIdSize)
+	copy(data, frame[MessageIdSize:])
+	return messageId, data, nil
+}
+
+//encode text frame
+func (p *Packet) Encode() []byte {
+	return p.BaseJson.Encode(p)
+}
+
+//decode text frame
+//frame is untrusted, so decode silently and return error
+func (p *Packet) Decode(frame []byte) error {
+	return sysJson.Unmarshal(frame, p)
+}
@@ -0,0 +1,279 @@
+package ws
+
+import (
+	"errors"
+	"github.com/andyzhou/tinygate"
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/iface"
+	pb "github.com/andyzhou/tinygate/proto"
+	"github.com/gorilla/websocket"
+	"log"
+	"net"
+	"net/http"
+)
+
+/*
+ * websocket front end server
+ *
+ * - accept websocket end users, binary and text frames
+ * - assign uint32 connect id for each connect
+ * - forward inbound frames to sub service pass gate client
+ * - fan out downstream messages to connects by `ConnIds`
+ * - can be used as http.Handler or standalone listener
+ * - should be created before `AddGateServer` of the gate client,
+ *   so that the stream received cb can be set on all gates.
+ * - own the stream received cb of gate client, if it has been set,
+ *   `Dispatch` should be called in that cb manually.
+ */
+
+//server info
+type Server struct {
+	address string //listen address, host:port
+	path string //websocket path for standalone listener
+	kind string //default sub service kind
+	client *tinygate.Client //gate client
+	upgrader websocket.Upgrader
+	httpServer *http.Server
+	registry iface.IConnRegistry //shared connect registry of gate client
+	writeChanSize int //per connect write buffer size
+	cbForKind func(connId, messageId uint32) string //cb for pick service kind
+	cbForConnClosed func(connId uint32) bool //cb for end user connect closed
+}
+
+//construct
+func NewServer(
+			address, kind string,
+			client *tinygate.Client,
+		) *Server {
+	//self init
+	this := &Server{
+		address:address,
+		path:define.WsPath,
+		kind:kind,
+		client:client,
+		upgrader:websocket.Upgrader{
+			ReadBufferSize:define.WsBufferSize,
+			WriteBufferSize:define.WsBufferSize,
+		},
+		writeChanSize:define.ConnWriteChanSize,
+	}
+
+	//set cb for downstream data
+	//front end owns the stream received cb of gate client
+	if client != nil {
+		this.registry = client.GetConnRegistry()
+		if !client.SetCBForStreamReceived(this.Dispatch) {
+			log.Println("Server::NewServer, stream received cb has been set, " +
+						"call `Dispatch` in it manually")
+		}
+	}
+	return this
+}
+
+//quit
+func (s *Server) Quit() {
+	//try catch panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Server:Quit panic, err:", err)
+		}
+	}()
+
+	//stop http server
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+
+	//close all connects
+	for _, conn := range s.GetAllConn() {
+		conn.Close()
+	}
+}
+
+//start standalone listener
+func (s *Server) Start() error {
+	//basic check
+	if s.address == "" || s.client == nil {
+		return errors.New("invalid parameter")
+	}
+
+	//init http server
+	mux := http.NewServeMux()
+	mux.Handle(s.path, s)
+	s.httpServer = &http.Server{
+		Addr:s.address,
+		Handler:mux,
+	}
+
+	//listen first, return error directly
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	//spawn http service
+	go func() {
+		err := s.httpServer.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Println("Server::Start, listen failed, err:", err.Error())
+		}
+	}()
+	return nil
+}
+
+//implement of http.Handler, upgrade to websocket
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	//basic check
+	if s.registry == nil {
+		http.Error(w, "gate client not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	//upgrade connect
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println("Server::ServeHTTP, upgrade failed, err:", err.Error())
+		return
+	}
+
+	//init new connect, register before start
+	newConn := NewConn(s.registry.NewConnId(), conn, s, s.writeChanSize)
+	s.registry.Add(newConn)
+	newConn.start()
+}
+
+//dispatch downstream message to front end connects
+//can be called manually if stream received cb has been set outside
+func (s *Server) Dispatch(from string, in *pb.ByteMessage) bool {
+	if s.registry == nil {
+		return false
+	}
+	return s.registry.Cast(in)
+}
+
+//get connect by id
+func (s *Server) GetConn(connId uint32) *Conn {
+	if s.registry == nil {
+		return nil
+	}
+	meta := s.registry.Get(connId)
+	if meta == nil {
+		return nil
+	}
+	conn, ok := meta.GetConn().(*Conn)
+	if !ok {
+		return nil
+	}
+	return conn
+}
+
+//get all connects of current front end
+func (s *Server) GetAllConn() []*Conn {
+	result := make([]*Conn, 0)
+	if s.registry == nil {
+		return result
+	}
+	s.registry.Range(func(meta iface.IConnMeta) bool {
+		conn, ok := meta.GetConn().(*Conn)
+		if ok && conn.server == s {
+			result = append(result, conn)
+		}
+		return true
+	})
+	return result
+}
+
+//set websocket path for standalone listener
+func (s *Server) SetPath(path string) bool {
+	if path == "" {
+		return false
+	}
+	s.path = path
+	return true
+}
+
+//set per connect write buffer size
+func (s *Server) SetWriteBufferSize(size int) bool {
+	if size <= 0 {
+		return false
+	}
+	s.writeChanSize = size
+	return true
+}
+
+//set cb for origin check
+//if not set, only same origin allowed
+func (s *Server) SetCBForCheckOrigin(cb func(r *http.Request) bool) bool {
+	if cb == nil {
+		return false
+	}
+	s.upgrader.CheckOrigin = cb
+	return true
+}
+
+//set cb for pick service kind by message id
+//if not set, use default kind
+func (s *Server) SetCBForKind(cb func(connId, messageId uint32) string) bool {
+	if cb == nil {
+		return false
+	}
+	s.cbForKind = cb
+	return true
+}
+
+//set cb for end user connect closed
+func (s *Server) SetCBForConnClosed(cb func(connId uint32) bool) bool {
+	if cb == nil {
+		return false
+	}
+	s.cbForConnClosed = cb
+	return true
+}
+
+////////////////
+//private func
+///////////////
+
+//forward inbound frame to sub service
+func (s *Server) forward(connId, messageId uint32, data []byte) bool {
+	//inter message id not allowed from end user
+	if messageId <= define.MessageIdOfInterMax {
+		return false
+	}
+
+	//pick service kind
+	kind := s.kind
+	if s.cbForKind != nil {
+		kind = s.cbForKind(connId, messageId)
+	}
+	if kind == "" {
+		return false
+	}
+
+	//update connect stat
+	meta := s.registry.Get(connId)
+	if meta != nil {
+		meta.AddBytesIn(len(data))
+	}
+
+	//init byte message
+	in := &pb.ByteMessage{
+		Service:kind,
+		MessageId:messageId,
+		Data:data,
+		ConnIds:[]uint32{connId},
+	}
+
+	//cast to sub service
+	return s.client.CastDataByKind(kind, in)
+}
+
+//remove closed connect
+func (s *Server) removeConn(connId uint32) {
+	ok := s.registry.Remove(connId)
+
+	//notify outside
+	if ok && s.cbForConnClosed != nil {
+		s.cbForConnClosed(connId)
+	}
+}
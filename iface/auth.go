@@ -0,0 +1,15 @@
+package iface
+
+import (
+	pb "github.com/andyzhou/tinygate/proto"
+)
+
+/*
+ * interface for access authenticator
+ * - used at gate server side
+ * - return authenticated app name
+ */
+
+type IAuthenticator interface {
+	Authenticate(auth *pb.AccessAuth) (string, error)
+}
@@ -0,0 +1,12 @@
+package iface
+
+/*
+ * interface for gate balancer
+ * - used at gate client side, one balancer one service kind
+ * - pick one gate from active gates of the same kind
+ * - gates are sorted by address, key is optional routing key
+ */
+
+type IBalancer interface {
+	Pick(key string, gates []IGate) IGate
+}
@@ -0,0 +1,19 @@
+package iface
+
+import (
+	"github.com/andyzhou/tinygate/json"
+)
+
+/*
+ * interface for player bind
+ */
+
+type IBind interface {
+	BindOrUnbind(in *json.BindJson) bool
+	RemoveByConn(connId uint32) bool
+
+	//get
+	GetByConn(connId uint32) *json.BindJson
+	GetByPlayer(playerId int64) *json.BindJson
+	GetNode(connId uint32, kind string) string
+}
@@ -1,7 +1,11 @@
 package iface
 
 import (
+	"context"
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/json"
 	pb "github.com/andyzhou/tinygate/proto"
+	"time"
 )
 
 /*
@@ -10,22 +14,64 @@ import (
 
 type IClient interface {
 	Quit()
+	Shutdown(ctx context.Context) (int, error)
 
 	//send gen request
 	SendGenReq(in *pb.GateReq) *pb.GateResp
+	SendGenReqByKey(in *pb.GateReq, key string) *pb.GateResp
+	SendGenReqCtx(ctx context.Context, in *pb.GateReq) (*pb.GateResp, error)
+	SendGenReqByKeyCtx(ctx context.Context, in *pb.GateReq, key string) (*pb.GateResp, error)
+	SendAsyncGenReq(in *pb.GateReq, cb func(resp *pb.GateResp)) uint64
+	Call(ctx context.Context, kind string, in *pb.ByteMessage) (*pb.ByteMessage, error)
+	SendGenValue(ctx context.Context, kind string, messageId uint32, req, resp interface{}) error
 
 	//cast stream data
 	CastData(address string, in *pb.ByteMessage) bool
 	CastDataByKind(kind string, in *pb.ByteMessage) bool
+	CastDataByKey(kind, key string, in *pb.ByteMessage) bool
 	CastDataToAll(in *pb.ByteMessage) bool
+	CastDataCtx(ctx context.Context, address string, in *pb.ByteMessage) error
+	CastDataByKindCtx(ctx context.Context, kind string, in *pb.ByteMessage) error
+	CastValue(ctx context.Context, kind string, messageId uint32, v interface{}, connIds ...uint32) error
 
 	//base opt
 	PickOneGateServer(kind string) IGate
+	PickOneGateServerByKey(kind, key string) IGate
 	AddGateServer(kind, host string, port int, tags ...string) bool
+	AddGateServerWithTLS(kind, host string, port int, tlsOption *define.TLSOption, tags ...string) bool
+	RemoveGateServer(address string) bool
+	SetRule(kind string, rule int) bool
+	SetStickyTable(table IStickyTable) bool
+	SetBalancer(kind string, balancer IBalancer) bool
+	SetBalancePolicy(kind string, policy int) bool
+	SetGateWeight(address string, weight int) bool
+	SetTimeout(kind string, timeout time.Duration) bool
+	SetCodecs(codecs ICodecRegistry) bool
+	GetCodecs() ICodecRegistry
+	SetQueueOption(option *define.QueueOption) bool
+	SetGateQueueOption(address string, option *define.QueueOption) bool
+	GetQueueStats() map[string]define.QueueStat
+	AddStreamInterceptor(interceptor StreamInterceptor) bool
+	AddGenInterceptor(interceptor GenInterceptor) bool
+	GetStickyTable() IStickyTable
 	SetLog(dir, tag string) bool
+	GetConnRegistry() IConnRegistry
+	SetHeartBeat(rate time.Duration, maxMiss int) bool
+	GetGateRTT(address string) time.Duration
+
+	//player bind
+	GetBindByConn(connId uint32) *json.BindJson
+	GetBindByPlayer(playerId int64) *json.BindJson
 
 	//set cb func
 	SetCBForStreamReceived(cb func(from string, in *pb.ByteMessage) bool) bool
 	SetCBForGateServerDown(cb func(kind, addr string) bool) bool
 	SetCBForGateServerUp(cb func(kind, addr string) bool) bool
+	SetCBForGateStatus(cb func(kind, addr string, from, to pb.NodeStatus) bool) bool
+	SetCBForQueueHighWater(cb func(kind, addr string, depth int, over bool) bool) bool
+	SetCBForKeysMoved(cb func(kind, from string, moved map[string]string) bool) bool
+	SetCBForAccessAuth(cb func(kind string) *pb.AccessAuth) bool
+	SetCBForAsyncResp(cb func(from string, reqId uint64, resp *pb.GateResp) bool) bool
+	SetCBForServiceReq(cb func(from string, in *pb.ByteMessage) *pb.ByteMessage) bool
+	SetAccessAuth(app, token string) bool
 }
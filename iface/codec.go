@@ -0,0 +1,23 @@
+package iface
+
+/*
+ * interface for message codec
+ * - used at client and service side
+ * - codec encode or decode `Data` of message
+ * - registry bind message id with go type and codec
+ */
+
+type ICodec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type ICodecRegistry interface {
+	Register(messageId uint32, sample interface{}, codec ICodec) error
+	Unregister(messageId uint32)
+	GetCodec(messageId uint32) ICodec
+	Encode(messageId uint32, v interface{}) ([]byte, error)
+	Decode(messageId uint32, data []byte) (interface{}, error)
+	DecodeInto(messageId uint32, data []byte, v interface{}) error
+}
@@ -0,0 +1,66 @@
+package iface
+
+import (
+	pb "github.com/andyzhou/tinygate/proto"
+	"time"
+)
+
+/*
+ * interface for front end connect and registry
+ * - used at gate client side
+ */
+
+//front end connect, tcp/websocket, etc.
+type IConn interface {
+	Close()
+	Write(in *pb.ByteMessage) error
+	GetConnId() uint32
+	GetRemoteAddr() string
+}
+
+//connect meta data
+type IConnMeta interface {
+	Close()
+	Write(in *pb.ByteMessage) error
+
+	//get
+	GetConn() IConn
+	GetConnId() uint32
+	GetRemoteAddr() string
+	GetOpenTime() time.Time
+	GetBytesIn() uint64
+	GetBytesOut() uint64
+
+	//stat
+	AddBytesIn(size int)
+	AddBytesOut(size int)
+
+	//auth
+	SetAuthed(authed bool)
+	IsAuthed() bool
+
+	//attributes
+	SetAttr(key string, val interface{})
+	GetAttr(key string) interface{}
+	DelAttr(key string)
+}
+
+//connect registry
+type IConnRegistry interface {
+	Quit()
+
+	//base opt
+	NewConnId() uint32
+	Add(conn IConn) IConnMeta
+	Remove(connId uint32) bool
+	Close(connId uint32) bool
+	Cast(in *pb.ByteMessage) bool
+
+	//get
+	Get(connId uint32) IConnMeta
+	Range(cb func(meta IConnMeta) bool)
+	Count() int
+
+	//set cb
+	AddCBForConnClosed(cb func(connId uint32) bool) bool
+}
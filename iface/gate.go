@@ -1,6 +1,12 @@
 package iface
 
-import pb "github.com/andyzhou/tinygate/proto"
+import (
+	"context"
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/json"
+	pb "github.com/andyzhou/tinygate/proto"
+	"time"
+)
 
 /*
  * interface for gate for client side
@@ -8,20 +14,49 @@ import pb "github.com/andyzhou/tinygate/proto"
 
 type IGate interface {
 	Quit()
+	Shutdown(ctx context.Context) (int, error)
 	SendGenReq(in *pb.GateReq) *pb.GateResp
+	SendGenReqCtx(ctx context.Context, in *pb.GateReq) (*pb.GateResp, error)
+	SendAsyncGenReq(in *pb.GateReq, cb func(resp *pb.GateResp)) uint64
+	Call(ctx context.Context, in *pb.ByteMessage) (*pb.ByteMessage, error)
 	CastData(in *pb.ByteMessage) bool
+	CastDataCtx(ctx context.Context, in *pb.ByteMessage) error
 	Connect(isReConn bool) bool
 
 	//get
 	GetKind() string //service kind
+	GetAddress() string //remote server address
 	GetTags() []string //unique tags
 	GetConnStat()string
+	GetRTT() time.Duration
+	GetLastActive() time.Time
+	GetInFlight() int64
+	GetLatency() time.Duration
+	GetWeight() int
+	GetTimeout() time.Duration
+	GetStatus() pb.NodeStatus
+	GetQueueStat() define.QueueStat
+
+	//set
+	SetHeartBeat(rate time.Duration, maxMiss int) bool
+	SetWeight(weight int) bool
+	SetTimeout(timeout time.Duration) bool
+	SetQueueOption(option *define.QueueOption) bool
 
 	//check
 	ConnIsNil() bool
+	IsActive() bool
+	IsAvailable() bool
 
 	//set cb
 	SetCBForStreamReceived(cb func(from string, in *pb.ByteMessage) bool) bool
 	SetCBForGateServerDown(cb func(kind, address string) bool) bool
 	SetCBForGateServerUp(cb func(kind, address string) bool) bool
+	SetCBForStatusChanged(cb func(kind, address string, from, to pb.NodeStatus) bool) bool
+	SetCBForBind(cb func(from string, in *json.BindJson) bool) bool
+	SetCBForAccessAuth(cb func(kind string) *pb.AccessAuth) bool
+	SetCBForAsyncResp(cb func(from string, reqId uint64, resp *pb.GateResp) bool) bool
+	SetCBForServiceReq(cb func(from string, in *pb.ByteMessage) *pb.ByteMessage) bool
+	SetInterceptor(interceptor IInterceptor) bool
+	SetCBForQueueHighWater(cb func(kind, address string, depth int, over bool) bool) bool
 }
@@ -0,0 +1,28 @@
+package iface
+
+import (
+	"github.com/andyzhou/tinygate/define"
+	pb "github.com/andyzhou/tinygate/proto"
+)
+
+/*
+ * interface for interceptor chain
+ * - used at client and service side
+ * - first added interceptor is the outermost one
+ * - interceptor should call next to continue, or return to break
+ */
+
+//handler and interceptor of stream data
+type StreamHandler func(meta *define.ReqMeta, in *pb.ByteMessage) error
+type StreamInterceptor func(meta *define.ReqMeta, in *pb.ByteMessage, next StreamHandler) error
+
+//handler and interceptor of general request
+type GenHandler func(meta *define.ReqMeta, in *pb.GateReq) (*pb.GateResp, error)
+type GenInterceptor func(meta *define.ReqMeta, in *pb.GateReq, next GenHandler) (*pb.GateResp, error)
+
+type IInterceptor interface {
+	AddStream(interceptor StreamInterceptor) bool
+	AddGen(interceptor GenInterceptor) bool
+	InterceptStream(meta *define.ReqMeta, in *pb.ByteMessage, final StreamHandler) error
+	InterceptGen(meta *define.ReqMeta, in *pb.GateReq, final GenHandler) (*pb.GateResp, error)
+}
@@ -1,7 +1,9 @@
 package iface
 
 import (
+	"github.com/andyzhou/tinygate/define"
 	pb "github.com/andyzhou/tinygate/proto"
+	"time"
 )
 
 /*
@@ -12,9 +14,18 @@ import (
  	Quit()
  	GetService(address string) IService
  	GetAllService() map[string]IService
+ 	GetRemoteAddr(identity string) string
+ 	RegisterNode(address, kind, tag string) bool
  	ClientNodeDown(address string) bool
  	ClientNodeUp(address string, stream *pb.GateService_BindStreamServer) bool
 
+ 	SetHeartBeat(rate time.Duration, maxMiss int) bool
+ 	GetHeartBeat() (time.Duration, int)
+ 	SetStatus(status pb.NodeStatus) bool
+ 	GetStatus() pb.NodeStatus
+ 	SetQueueOption(option *define.QueueOption) bool
+
  	//set cb for client node down
  	SetCBForClientNodeDown(cb func(remoteAddr string) bool) bool
+ 	SetCBForQueueHighWater(cb func(remoteAddr string, depth int, over bool) bool) bool
  }
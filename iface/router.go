@@ -0,0 +1,37 @@
+package iface
+
+import (
+	"context"
+	pb "github.com/andyzhou/tinygate/proto"
+)
+
+/*
+ * interface for message router
+ * - used at sub service side
+ * - handler by message id or message id range
+ * - separate handler table for stream and general request
+ * - sub router can be mounted by service kind
+ */
+
+type IRouter interface {
+	//dispatch
+	DispatchStream(remoteAddr string, in *pb.ByteMessage) bool
+	DispatchGen(in *pb.GateReq) *pb.GateResp
+	DispatchGenCtx(ctx context.Context, in *pb.GateReq) *pb.GateResp
+
+	//register handler
+	HandleStream(messageId uint32, handler func(remoteAddr string, in *pb.ByteMessage) bool) bool
+	HandleStreamRange(begin, end uint32, handler func(remoteAddr string, in *pb.ByteMessage) bool) bool
+	HandleGen(messageId uint32, handler func(in *pb.GateReq) *pb.GateResp) bool
+	HandleGenCtx(messageId uint32, handler func(ctx context.Context, in *pb.GateReq) *pb.GateResp) bool
+	HandleGenRange(begin, end uint32, handler func(in *pb.GateReq) *pb.GateResp) bool
+	HandleStreamValue(messageId uint32, handler func(remoteAddr string, in *pb.ByteMessage, v interface{}) bool) bool
+	HandleGenValue(messageId uint32, handler func(in *pb.GateReq, v interface{}) (interface{}, error)) bool
+	Mount(kind string, sub IRouter) bool
+	SetCodecs(codecs ICodecRegistry) bool
+
+	//set cb
+	SetCBForStreamNotFound(cb func(remoteAddr string, in *pb.ByteMessage) bool) bool
+	SetCBForGenNotFound(cb func(in *pb.GateReq) *pb.GateResp) bool
+	SetCBForCodecError(cb func(remoteAddr string, err error) bool) bool
+}
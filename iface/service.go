@@ -1,7 +1,10 @@
 package iface
 
 import (
+	"context"
+	"github.com/andyzhou/tinygate/define"
 	pb "github.com/andyzhou/tinygate/proto"
+	"time"
 )
 
 /*
@@ -10,7 +13,23 @@ import (
 
  type IService interface {
  	Quit()
+ 	Done() <-chan struct{}
  	SendClientResp(resp *pb.ByteMessage) bool
+ 	SendClientRespCtx(ctx context.Context, resp *pb.ByteMessage) error
  	GetRemoteAddr() string
  	GetStream() *pb.GateService_BindStreamServer
+ 	UpdateActive()
+ 	SetApp(app string)
+ 	GetApp() string
+ 	SetIdentity(identity string)
+ 	GetIdentity() string
+ 	SetNode(kind, tag string)
+ 	GetKind() string
+ 	GetTag() string
+ 	GetLastActive() time.Time
+ 	GetQueueLen() int
+ 	GetPendingLen() int
+ 	GetQueueStat() define.QueueStat
+ 	SetQueueOption(option *define.QueueOption) bool
+ 	SetCBForQueueHighWater(cb func(remoteAddr string, depth int, over bool) bool) bool
  }
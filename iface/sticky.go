@@ -0,0 +1,20 @@
+package iface
+
+/*
+ * interface for sticky assignment table
+ * - used for persistent routing rule
+ * - key -> gate address by service kind
+ * - can be kept in memory or other storage
+ */
+
+type IStickyTable interface {
+	Get(kind, key string) string
+	Set(kind, key, address string)
+	Remove(kind, key string)
+	RemoveKey(key string)
+	GetKeys(kind, address string) []string
+
+	//snapshot
+	Snapshot() []byte
+	Restore(data []byte) error
+}
@@ -0,0 +1,39 @@
+package json
+
+/*
+ * json for async general response
+ * - inter used for async general request
+ * - send from sub service pass bind stream
+ * - correlated by request id from client api
+ */
+
+//json info
+type AsyncRespJson struct {
+	ReqId uint64 `json:"reqId"`
+	Service string `json:"service"`
+	MessageId uint32 `json:"messageId"`
+	Data []byte `json:"data"`
+	ErrorCode int32 `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+	BaseJson
+}
+
+/////////////////////////////
+//construct for AsyncRespJson
+/////////////////////////////
+
+//construct
+func NewAsyncRespJson() *AsyncRespJson {
+	this := &AsyncRespJson{}
+	return this
+}
+
+//encode json data
+func (j *AsyncRespJson) Encode() []byte {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *AsyncRespJson) Decode(data []byte) bool {
+	return j.BaseJson.Decode(data, j)
+}
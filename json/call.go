@@ -0,0 +1,40 @@
+package json
+
+/*
+ * json for stream call
+ * - inter used for request and response pass bind stream
+ * - correlated by seq from client api
+ * - timeout of call request is left time of client deadline
+ * - wrap real message id and data
+ * - used for both client call and sub service request
+ */
+
+//json info
+type CallJson struct {
+	Seq uint64 `json:"seq"`
+	MessageId uint32 `json:"messageId"`
+	Data []byte `json:"data"`
+	Error string `json:"error,omitempty"` //error message of reply
+	Timeout int64 `json:"timeout,omitempty"` //left time of call request, milliseconds
+	BaseJson
+}
+
+/////////////////////////////
+//construct for CallJson
+/////////////////////////////
+
+//construct
+func NewCallJson() *CallJson {
+	this := &CallJson{}
+	return this
+}
+
+//encode json data
+func (j *CallJson) Encode() []byte {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *CallJson) Decode(data []byte) bool {
+	return j.BaseJson.Decode(data, j)
+}
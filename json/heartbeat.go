@@ -0,0 +1,36 @@
+package json
+
+/*
+ * json for heart beat
+ * - inter used for node alive check
+ * - auto send from client api, echo by sub service
+ * - sub service send its expectation after node up, without time
+ */
+
+//json info
+type HeartBeatJson struct {
+	Time int64 `json:"time"` //send time, unix nano seconds
+	Rate int64 `json:"rate,omitempty"` //expected beat rate of sub service, milliseconds
+	MaxMiss int `json:"maxMiss,omitempty"` //max missed beats of sub service
+	BaseJson
+}
+
+/////////////////////////////
+//construct for HeartBeatJson
+/////////////////////////////
+
+//construct
+func NewHeartBeatJson() *HeartBeatJson {
+	this := &HeartBeatJson{}
+	return this
+}
+
+//encode json data
+func (j *HeartBeatJson) Encode() []byte {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *HeartBeatJson) Decode(data []byte) bool {
+	return j.BaseJson.Decode(data, j)
+}
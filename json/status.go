@@ -0,0 +1,34 @@
+package json
+
+/*
+ * json for node status
+ * - inter used for status notify by sub service
+ * - sent after client node up and when status changed
+ * - status is `pb.NodeStatus`
+ */
+
+//json info
+type StatusJson struct {
+	Status int32 `json:"status"`
+	BaseJson
+}
+
+/////////////////////////////
+//construct for StatusJson
+/////////////////////////////
+
+//construct
+func NewStatusJson() *StatusJson {
+	this := &StatusJson{}
+	return this
+}
+
+//encode json data
+func (j *StatusJson) Encode() []byte {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *StatusJson) Decode(data []byte) bool {
+	return j.BaseJson.Decode(data, j)
+}
@@ -6,9 +6,16 @@ import (
 	"github.com/andyzhou/tinygate/define"
 	"github.com/andyzhou/tinygate/iface"
 	pb "github.com/andyzhou/tinygate/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"github.com/andyzhou/tinygate/json"
 	"io"
 	"log"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 /*
@@ -25,13 +32,43 @@ import (
  	byteMessage pb.ByteMessage
  }
 
+ //stopper of stream receive process
+ //bind stream wait for message in process before return
+ type receiveStopper struct {
+ 	stopped bool
+ 	sync.Mutex
+ }
+
+ //pending stream call from client
+ type pendingCall struct {
+ 	remoteAddr string
+ 	seq uint64
+ 	expire time.Time
+ }
+
+ //waiter of request to client node
+ type requestWaiter struct {
+ 	remoteAddr string
+ 	replyChan chan *json.CallJson
+ }
+
  //service info
  type Service struct {
  	node iface.INode
+ 	authenticator iface.IAuthenticator //access authenticator, optional
+ 	interceptor iface.IInterceptor //inbound interceptor chain, optional
  	clientStreamMap map[string]pb.GateService_BindStreamServer //remoteAddr -> stream interface
  	cbForStreamReq func(remoteAddr string, req *pb.ByteMessage) bool //cb for client stream request
- 	cbForGenReq func(req *pb.GateReq) *pb.GateResp //cb for client gen request
+ 	cbForGenReq func(ctx context.Context, req *pb.GateReq) *pb.GateResp //cb for client gen request
+	cbForClientConnClosed func(remoteAddr string, connId uint32) bool //cb for front end connect closed
+	pendingMap map[uint64]*pendingCall //pending stream calls, callId -> pendingCall
+	callId uint64 //last correlation id of stream call from client node
+	seq uint64 //last request seq to client node
+	waiterMap map[uint64]*requestWaiter //pending requests to client node, seq -> requestWaiter
 	respChan chan Response //chan for send response
+	inFlight int64 //in flight general requests
+	drainChan chan struct{} //closed when service draining
+	drainOnce sync.Once
 	closeChan chan struct{}
  	Base
  	sync.RWMutex
@@ -43,8 +80,14 @@ func NewService() *Service {
 	this := &Service{
 		clientStreamMap: make(map[string]pb.GateService_BindStreamServer),
 		respChan:make(chan Response, define.ResponseChanSize),
+		drainChan:make(chan struct{}),
 		closeChan:make(chan struct{}, 1),
+		pendingMap:make(map[uint64]*pendingCall),
+		waiterMap:make(map[uint64]*requestWaiter),
 	}
+
+	//spawn main process
+	go this.runMainProcess()
 	return this
 }
 
@@ -74,8 +117,41 @@ func (r *Service) SetNodeFace(node iface.INode) error {
 	return nil
 }
 
+//set access authenticator
+func (r *Service) SetAuthenticator(authenticator iface.IAuthenticator) error {
+	if authenticator == nil {
+		return errors.New("invalid parameter")
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.authenticator = authenticator
+	return nil
+}
+
+//set inbound interceptor chain
+func (r *Service) SetInterceptor(interceptor iface.IInterceptor) error {
+	if interceptor == nil {
+		return errors.New("invalid parameter")
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.interceptor = interceptor
+	return nil
+}
+
 //set cb for client general request
 func (r *Service) SetCBForGenReq(cb func(req *pb.GateReq) *pb.GateResp) error {
+	if cb == nil {
+		return errors.New("invalid parameter")
+	}
+	return r.SetCBForGenReqCtx(func(ctx context.Context, req *pb.GateReq) *pb.GateResp {
+		return cb(req)
+	})
+}
+
+//set cb for client general request with context
+//context derived from request, values kept for async request
+func (r *Service) SetCBForGenReqCtx(cb func(ctx context.Context, req *pb.GateReq) *pb.GateResp) error {
 	if cb == nil {
 		return errors.New("invalid parameter")
 	}
@@ -85,6 +161,13 @@ func (r *Service) SetCBForGenReq(cb func(req *pb.GateReq) *pb.GateResp) error {
 	return nil
 }
 
+//check cb for client general request is set or not
+func (r *Service) HasCBForGenReq() bool {
+	r.RLock()
+	defer r.RUnlock()
+	return r.cbForGenReq != nil
+}
+
 //set cb for client stream request
 func (r *Service) SetCBForStreamReq(cb func(remoteAddr string, req *pb.ByteMessage) bool) error {
 	if cb == nil {
@@ -96,6 +179,138 @@ func (r *Service) SetCBForStreamReq(cb func(remoteAddr string, req *pb.ByteMessa
 	return nil
 }
 
+//set cb for front end connect closed on client node
+func (r *Service) SetCBForClientConnClosed(cb func(remoteAddr string, connId uint32) bool) error {
+	if cb == nil {
+		return errors.New("invalid parameter")
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.cbForClientConnClosed = cb
+	return nil
+}
+
+ //reply stream call from client
+ //req should be the request passed to cb for stream request,
+ //or a copy of it, correlation id is carried by `req.CallId`.
+func (r *Service) Reply(req *pb.ByteMessage, resp *pb.ByteMessage) error {
+	//basic check
+	if req == nil || resp == nil {
+		return errors.New("invalid parameter")
+	}
+	if req.CallId <= 0 {
+		return define.ErrCallNotFound
+	}
+
+	//get and remove pending call
+	r.Lock()
+	call, ok := r.pendingMap[req.CallId]
+	delete(r.pendingMap, req.CallId)
+	r.Unlock()
+	if !ok || time.Now().After(call.expire) {
+		//unknown, replied, canceled or expired
+		return define.ErrCallNotFound
+	}
+
+	//get client node
+	service := r.node.GetService(call.remoteAddr)
+	if service == nil {
+		return errors.New("client node has been expired")
+	}
+
+	//init call json
+	callJson := json.NewCallJson()
+	callJson.Seq = call.seq
+	callJson.MessageId = resp.MessageId
+	callJson.Data = resp.Data
+
+	//send to client node pass bind stream
+	if !service.SendClientResp(&pb.ByteMessage{
+		Service:req.Service,
+		MessageId:define.MessageIdOfCallResp,
+		Data:callJson.Encode(),
+	}) {
+		return errors.New("send reply failed")
+	}
+	return nil
+}
+
+ //send request to client node, wait for reply
+ //if context without deadline, use default timeout
+func (r *Service) Request(
+				ctx context.Context,
+				remoteAddr string,
+				in *pb.ByteMessage,
+			) (*pb.ByteMessage, error) {
+	//basic check
+	if ctx == nil || remoteAddr == "" || in == nil {
+		return nil, define.ErrInvalidParameter
+	}
+	service := r.node.GetService(remoteAddr)
+	if service == nil {
+		return nil, define.ErrNoClientNode
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Second * define.CallReqTimeout)
+		defer cancel()
+	}
+
+	//add into waiter map before send
+	seq := atomic.AddUint64(&r.seq, 1)
+	waiter := &requestWaiter{
+		remoteAddr:service.GetRemoteAddr(),
+		replyChan:make(chan *json.CallJson, 1),
+	}
+	r.Lock()
+	r.waiterMap[seq] = waiter
+	r.Unlock()
+	defer func() {
+		r.Lock()
+		delete(r.waiterMap, seq)
+		r.Unlock()
+	}()
+
+	//init call json
+	callJson := json.NewCallJson()
+	callJson.Seq = seq
+	callJson.MessageId = in.MessageId
+	callJson.Data = in.Data
+
+	//send to client node pass bind stream
+	err := service.SendClientRespCtx(ctx, &pb.ByteMessage{
+		Service:in.Service,
+		MessageId:define.MessageIdOfServiceReq,
+		Data:callJson.Encode(),
+		ConnIds:in.ConnIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//wait for reply
+	select {
+	case reply, ok := <- waiter.replyChan:
+		if !ok {
+			return nil, define.ErrNoClientNode
+		}
+		if reply.Error != "" {
+			return nil, errors.New(reply.Error)
+		}
+		resp := &pb.ByteMessage{
+			Service:in.Service,
+			MessageId:reply.MessageId,
+			Data:reply.Data,
+		}
+		return resp, nil
+	case <- ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, define.ErrTimeout
+		}
+		return nil, ctx.Err()
+	}
+}
+
  //send stream data to remote client
 func (r *Service) SendToClient(remoteAddr string, in *pb.ByteMessage) error {
 	//basic check
@@ -115,7 +330,9 @@ func (r *Service) SendToClient(remoteAddr string, in *pb.ByteMessage) error {
 }
 
 //implement interface of `GenReq`
-//this is sync request
+//sync request by default,
+//async request will be acknowledged immediately,
+//response will be sent back pass bind stream.
 func (r *Service) GenReq(ctx context.Context, in *pb.GateReq) (*pb.GateResp, error) {
 	if in == nil {
 		return nil, errors.New("invalid parameter")
@@ -123,26 +340,47 @@ func (r *Service) GenReq(ctx context.Context, in *pb.GateReq) (*pb.GateResp, err
 	if r.cbForGenReq == nil {
 		return nil, errors.New("invalid cb for gen request")
 	}
+	if r.isDraining() {
+		return nil, status.Error(codes.Unavailable, "service is draining")
+	}
 
-	//call the cb func to process general requests
-	resp := r.cbForGenReq(in)
-	if resp == nil {
-		return nil, errors.New("invalid response")
+	//check access auth
+	//authenticated app will be passed to cb
+	if authenticator := r.getAuthenticator(); authenticator != nil {
+		app, err := authenticator.Authenticate(in.Auth)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		in.Auth = &pb.AccessAuth{
+			App:app,
+		}
 	}
-	return resp, nil
+
+	//async request
+	if in.IsAsync {
+		return r.asyncGenReq(ctx, in)
+	}
+
+	//call the cb func to process general requests
+	//count in flight request, used for drain
+	atomic.AddInt64(&r.inFlight, 1)
+	defer atomic.AddInt64(&r.inFlight, -1)
+	return r.handleGenReq(ctx, r.getGenReqMeta(ctx, in), in)
 }
 
  //implement interface of `BindStream`
  //receive stream data from rpc client side
 func (r *Service) BindStream(stream pb.GateService_BindStreamServer) error {
 	var (
-		in *pb.ByteMessage
-		err error
 		tips string
 		remoteAddr string
-		messageId uint32
 	)
 
+	//reject new stream when draining
+	if r.isDraining() {
+		return status.Error(codes.Unavailable, "service is draining")
+	}
+
 	//get context
 	ctx := stream.Context()
 
@@ -157,13 +395,27 @@ func (r *Service) BindStream(stream pb.GateService_BindStreamServer) error {
 	//get remote addr
 	remoteAddr = tag.RemoteAddr.String()
 
+	//check access auth from metadata
+	app, err := r.authStream(ctx)
+	if err != nil {
+		return err
+	}
+
 	//add remote stream into map
 	r.Lock()
 	r.clientStreamMap[remoteAddr] = stream
 	r.Unlock()
 
 	//client node up
+	//client node may be expired by heart beat, then close stream
+	var doneChan <-chan struct{}
 	r.node.ClientNodeUp(remoteAddr, &stream)
+	service := r.node.GetService(remoteAddr)
+	if service != nil {
+		service.SetApp(app)
+		service.SetIdentity(r.GetPeerIdentity(ctx))
+		doneChan = service.Done()
+	}
 
 	//defer
 	defer func() {
@@ -174,40 +426,506 @@ func (r *Service) BindStream(stream pb.GateService_BindStreamServer) error {
 		r.Lock()
 		delete(r.clientStreamMap, remoteAddr)
 		r.Unlock()
+		r.cleanWaiter(remoteAddr)
+	}()
+
+	//spawn new process for receive stream data from node
+	//stream will be closed when service draining,
+	//receive process stopped before return, no message processed after that.
+	errChan := make(chan error, 1)
+	stopper := &receiveStopper{}
+	defer stopper.stop()
+	go func() {
+		errChan <- r.receiveStream(remoteAddr, stream, stopper)
 	}()
 
-	//try receive stream data from node
+	//wait for receive done or service draining
+	select {
+	case err = <- errChan:
+		return err
+	case <- ctx.Done():
+		log.Println("Stream::BindStream, Receive down signal from client")
+		return ctx.Err()
+	case <- r.drainChan:
+		log.Println("Stream::BindStream, Service draining, close stream")
+		return nil
+	case <- doneChan:
+		log.Println("Stream::BindStream, Client node expired, close stream")
+		return errors.New("client node has been expired")
+	}
+}
+
+//drain service
+//all bind streams will be closed, new stream and general request rejected
+func (r *Service) Drain() {
+	r.drainOnce.Do(func() {
+		close(r.drainChan)
+	})
+}
+
+//get count of in flight general requests
+func (r *Service) GetInFlight() int64 {
+	return atomic.LoadInt64(&r.inFlight)
+}
+
+////////////////
+//private func
+////////////////
+
+//check service is draining or not
+func (r *Service) isDraining() bool {
+	select {
+	case <- r.drainChan:
+		return true
+	default:
+		return false
+	}
+}
+
+//receive stream data from client node
+func (r *Service) receiveStream(
+					remoteAddr string,
+					stream pb.GateService_BindStreamServer,
+					stopper *receiveStopper,
+				) (err error) {
+	var (
+		in *pb.ByteMessage
+		isRun bool
+	)
+
+	//try catch panic
+	defer func() {
+		if subErr := recover(); subErr != nil {
+			log.Println("Stream::receiveStream panic, err:", subErr)
+			err = errors.New("receive stream panic")
+		}
+	}()
+
+	//loop receive
 	for {
-		select {
-		case <- ctx.Done():
-			log.Println("Stream::BindStream, Receive down signal from client")
-			return ctx.Err()
-		default:
-			//receive data from client
-			in, err = stream.Recv()
-			if err == io.EOF {
-				log.Println("Stream::BindStream, Read done")
-				return nil
-			}
-			if err != nil {
-				log.Printf("Stream::BindStream, Read error:%v", err.Error())
-				return err
-			}
+		//receive data from client
+		in, err = stream.Recv()
+		if err == io.EOF {
+			log.Println("Stream::BindStream, Read done")
+			return nil
+		}
+		if err != nil {
+			log.Printf("Stream::BindStream, Read error:%v", err.Error())
+			return err
+		}
+
+		//process message if not stopped by bind stream
+		isRun, err = stopper.run(func() error {
+			return r.processStream(remoteAddr, in)
+		})
+		if !isRun {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+//process stream data from client node
+func (r *Service) processStream(remoteAddr string, in *pb.ByteMessage) error {
+	//update client node active time
+	//if client node has been expired, close stream
+	service := r.node.GetService(remoteAddr)
+	if service == nil {
+		return errors.New("client node has been expired")
+	}
+	service.UpdateActive()
 
-			//get message id
-			messageId = in.MessageId
-
-			//do relate opt by message id
-			switch messageId {
-			default:
-				{
-					//input stream data from rpc client node side
-					if r.cbForStreamReq != nil {
-						r.cbForStreamReq(remoteAddr, in)
-					}
+	//do relate opt by message id
+	switch in.MessageId {
+	case define.MessageIdOfNodeUp:
+		{
+			//node up handshake, register client node identity
+			r.nodeUp(remoteAddr, in)
+		}
+	case define.MessageIdOfHeartBeat:
+		{
+			//echo heart beat to client node
+			service.SendClientResp(in)
+		}
+	case define.MessageIdOfClientClosed:
+		{
+			//front end connect closed on client node
+			if r.cbForClientConnClosed != nil {
+				for _, connId := range in.ConnIds {
+					r.cbForClientConnClosed(remoteAddr, connId)
 				}
 			}
 		}
+	case define.MessageIdOfCallReq:
+		{
+			//stream call from client node, reply by `Reply`
+			r.callReq(remoteAddr, in)
+		}
+	case define.MessageIdOfCallCancel:
+		{
+			//stream call given up by client node
+			r.callCancel(remoteAddr, in)
+		}
+	case define.MessageIdOfServiceResp:
+		{
+			//reply of request to client node
+			r.serviceResp(in)
+		}
+	default:
+		{
+			//input stream data from rpc client node side
+			r.handleStreamReq(remoteAddr, in)
+		}
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+//run process if not stopped
+//return false if stopped
+func (s *receiveStopper) run(process func() error) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.stopped {
+		return false, nil
+	}
+	return true, process()
+}
+
+//stop, wait for running process
+func (s *receiveStopper) stop() {
+	s.Lock()
+	defer s.Unlock()
+	s.stopped = true
+}
+
+//process node up handshake from client node
+func (r *Service) nodeUp(remoteAddr string, in *pb.ByteMessage) bool {
+	nodeJson := json.NewNodeJson()
+	if !nodeJson.Decode(in.Data) {
+		return false
+	}
+	if !r.node.RegisterNode(remoteAddr, nodeJson.Kind, nodeJson.Tag) {
+		return false
+	}
+
+	//send heart beat expectation, client node adjust beat rate by it
+	service := r.node.GetService(remoteAddr)
+	if service == nil {
+		return false
+	}
+	rate, maxMiss := r.node.GetHeartBeat()
+	heartBeatJson := json.NewHeartBeatJson()
+	heartBeatJson.Rate = rate.Milliseconds()
+	heartBeatJson.MaxMiss = maxMiss
+	return service.SendClientResp(&pb.ByteMessage{
+		MessageId:define.MessageIdOfHeartBeat,
+		Data:heartBeatJson.Encode(),
+	})
+}
+
+//process stream call request
+func (r *Service) callReq(remoteAddr string, in *pb.ByteMessage) bool {
+	//decode call json
+	callJson := json.NewCallJson()
+	if !callJson.Decode(in.Data) {
+		return false
+	}
+
+	//unwrap real request with correlation id
+	callId := atomic.AddUint64(&r.callId, 1)
+	req := &pb.ByteMessage{
+		Service:in.Service,
+		MessageId:callJson.MessageId,
+		Data:callJson.Data,
+		Address:in.Address,
+		ConnIds:in.ConnIds,
+		CallId:callId,
+	}
+
+	//add into pending map
+	//expired with deadline of client, or default timeout
+	timeout := time.Second * define.CallReqTimeout
+	if callJson.Timeout > 0 {
+		timeout = time.Duration(callJson.Timeout) * time.Millisecond
+	}
+	r.Lock()
+	r.pendingMap[callId] = &pendingCall{
+		remoteAddr:remoteAddr,
+		seq:callJson.Seq,
+		expire:time.Now().Add(timeout),
+	}
+	r.Unlock()
+
+	//call cb for stream request
+	return r.handleStreamReq(remoteAddr, req) == nil
+}
+
+//process stream call given up by client node
+//remove pending call, later reply return `ErrCallNotFound`
+func (r *Service) callCancel(remoteAddr string, in *pb.ByteMessage) bool {
+	//decode call json
+	callJson := json.NewCallJson()
+	if !callJson.Decode(in.Data) {
+		return false
+	}
+
+	//remove pending call with locker
+	r.Lock()
+	defer r.Unlock()
+	for callId, call := range r.pendingMap {
+		if call.remoteAddr == remoteAddr && call.seq == callJson.Seq {
+			delete(r.pendingMap, callId)
+			return true
+		}
+	}
+	return false
+}
+
+//call cb for stream request pass interceptor chain
+func (r *Service) handleStreamReq(remoteAddr string, in *pb.ByteMessage) error {
+	//init final handler
+	final := func(meta *define.ReqMeta, in *pb.ByteMessage) error {
+		if r.cbForStreamReq != nil {
+			r.cbForStreamReq(meta.RemoteAddr, in)
+		}
+		return nil
+	}
+
+	//init meta
+	meta := &define.ReqMeta{
+		Direction:define.DirectionOfInbound,
+		Kind:in.Service,
+		RemoteAddr:remoteAddr,
+	}
+	service := r.node.GetService(remoteAddr)
+	if service != nil {
+		meta.App = service.GetApp()
+	}
+
+	//run interceptor chain
+	if r.interceptor == nil {
+		return final(meta, in)
+	}
+	return r.interceptor.InterceptStream(meta, in, final)
+}
+
+//get meta of general request
+func (r *Service) getGenReqMeta(ctx context.Context, in *pb.GateReq) *define.ReqMeta {
+	meta := &define.ReqMeta{
+		Direction:define.DirectionOfInbound,
+		Kind:in.Service,
+	}
+	tag, ok := r.GetConnTagFromContext(ctx)
+	if ok {
+		meta.RemoteAddr = tag.RemoteAddr.String()
+	}
+	if in.Auth != nil {
+		meta.App = in.Auth.App
+	}
+	return meta
+}
+
+//call cb for general request pass interceptor chain
+//caller should count in flight request
+func (r *Service) handleGenReq(
+				ctx context.Context,
+				meta *define.ReqMeta,
+				in *pb.GateReq,
+			) (*pb.GateResp, error) {
+	//init final handler
+	final := func(meta *define.ReqMeta, in *pb.GateReq) (*pb.GateResp, error) {
+		resp := r.cbForGenReq(ctx, in)
+		if resp == nil {
+			return nil, errors.New("invalid response")
+		}
+		return resp, nil
+	}
+
+	//run interceptor chain
+	if r.interceptor == nil {
+		return final(meta, in)
+	}
+	return r.interceptor.InterceptGen(meta, in, final)
+}
+
+//process reply of request to client node
+func (r *Service) serviceResp(in *pb.ByteMessage) bool {
+	//decode call json
+	callJson := json.NewCallJson()
+	if !callJson.Decode(in.Data) {
+		return false
+	}
+
+	//get and remove waiter
+	r.Lock()
+	waiter, ok := r.waiterMap[callJson.Seq]
+	delete(r.waiterMap, callJson.Seq)
+	r.Unlock()
+	if !ok {
+		//requester has been gone
+		return false
+	}
+
+	//send to waiter
+	waiter.replyChan <- callJson
+	return true
+}
+
+//cancel waiters of client node
+func (r *Service) cleanWaiter(remoteAddr string) {
+	r.Lock()
+	defer r.Unlock()
+	for seq, waiter := range r.waiterMap {
+		if waiter.remoteAddr != remoteAddr {
+			continue
+		}
+		close(waiter.replyChan)
+		delete(r.waiterMap, seq)
+	}
+}
+
+//clean expired pending calls
+func (r *Service) cleanPendingCall() {
+	now := time.Now()
+	r.Lock()
+	defer r.Unlock()
+	for callId, call := range r.pendingMap {
+		if now.After(call.expire) {
+			delete(r.pendingMap, callId)
+		}
+	}
+}
+
+//run main process
+func (r *Service) runMainProcess() {
+	var (
+		ticker = time.NewTicker(time.Second * define.CallCheckRate)
+	)
+
+	//defer
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("rpc Service:runMainProcess panic, err:", err)
+		}
+		ticker.Stop()
+	}()
+
+	//loop
+	for {
+		select {
+		case <- ticker.C:
+			r.cleanPendingCall()
+		case <- r.closeChan:
+			return
+		}
+	}
+}
+
+//process async general request
+//response will be sent to bind stream of same client node
+func (r *Service) asyncGenReq(ctx context.Context, in *pb.GateReq) (*pb.GateResp, error) {
+	//get request id from metadata
+	var reqId uint64
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		if v := md.Get(define.MetaKeyOfReqId); len(v) > 0 {
+			reqId, _ = strconv.ParseUint(v[0], 10, 64)
+		}
+	}
+	if reqId <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid async request id")
+	}
+
+	//get client node by connect tag
+	//general request and bind stream share the same connect
+	tag, ok := r.GetConnTagFromContext(ctx)
+	if !ok {
+		return nil, errors.New("can't get tag from context")
+	}
+	service := r.node.GetService(tag.RemoteAddr.String())
+	if service == nil {
+		return nil, status.Error(codes.FailedPrecondition, "no bind stream for async request")
+	}
+
+	//spawn new process for call the cb func
+	//count in flight before spawn, so drain can't miss it
+	//request context canceled after ack, keep values only
+	meta := r.getGenReqMeta(ctx, in)
+	reqCtx := context.WithoutCancel(ctx)
+	atomic.AddInt64(&r.inFlight, 1)
+	go func() {
+		//try catch panic
+		defer func() {
+			if err := recover(); err != nil {
+				log.Println("rpc Service::asyncGenReq panic, err:", err)
+			}
+			atomic.AddInt64(&r.inFlight, -1)
+		}()
+
+		//init async response json
+		asyncRespJson := json.NewAsyncRespJson()
+		asyncRespJson.ReqId = reqId
+		asyncRespJson.Service = in.Service
+		asyncRespJson.MessageId = in.MessageId
+		resp, err := r.handleGenReq(reqCtx, meta, in)
+		if err == nil {
+			asyncRespJson.Data = resp.Data
+			asyncRespJson.ErrorCode = resp.ErrorCode
+			asyncRespJson.ErrorMessage = resp.ErrorMessage
+		}else{
+			asyncRespJson.ErrorCode = define.AsyncRespErrCode
+			asyncRespJson.ErrorMessage = err.Error()
+		}
+
+		//send to client node pass bind stream
+		service.SendClientResp(&pb.ByteMessage{
+			Service:in.Service,
+			MessageId:define.MessageIdOfAsyncResp,
+			Data:asyncRespJson.Encode(),
+		})
+	}()
+
+	//acknowledge immediately
+	resp := &pb.GateResp{
+		Service:in.Service,
+		MessageId:in.MessageId,
+	}
+	return resp, nil
+}
+
+//get access authenticator with locker
+func (r *Service) getAuthenticator() iface.IAuthenticator {
+	r.RLock()
+	defer r.RUnlock()
+	return r.authenticator
+}
+
+//check access auth of bind stream from metadata
+//return authenticated app
+func (r *Service) authStream(ctx context.Context) (string, error) {
+	authenticator := r.getAuthenticator()
+	if authenticator == nil {
+		return "", nil
+	}
+
+	//get auth from metadata
+	auth := &pb.AccessAuth{}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		if v := md.Get(define.MetaKeyOfApp); len(v) > 0 {
+			auth.App = v[0]
+		}
+		if v := md.Get(define.MetaKeyOfToken); len(v) > 0 {
+			auth.Token = v[0]
+		}
+	}
+
+	//authenticate
+	app, err := authenticator.Authenticate(auth)
+	if err != nil {
+		return "", status.Error(codes.Unauthenticated, err.Error())
+	}
+	return app, nil
+}
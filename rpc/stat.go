@@ -2,9 +2,12 @@ package rpc
 
 import (
 	"context"
+	"fmt"
 	"github.com/andyzhou/tinygate/iface"
 	"google.golang.org/grpc/stats"
 	"log"
+	"net"
+	"sync/atomic"
 )
 
 /*
@@ -17,6 +20,7 @@ import (
 type Stat struct {
 	node iface.INode
 	base *Base
+	seq uint64 //seq for unnamed remote address, like unix socket
 }
 
 //construct
@@ -32,6 +36,16 @@ func (h *Stat) TagConn(
 					ctx context.Context,
 					info *stats.ConnTagInfo,
 				) context.Context {
+	//unix socket client has no remote address, gen unique one
+	if info.RemoteAddr == nil || info.RemoteAddr.String() == "" ||
+		info.RemoteAddr.String() == "@" {
+		tagInfo := *info
+		tagInfo.RemoteAddr = &net.UnixAddr{
+			Name:fmt.Sprintf("unix@%d", atomic.AddUint64(&h.seq, 1)),
+			Net:"unix",
+		}
+		info = &tagInfo
+	}
 	return context.WithValue(ctx, ConnCtxKey{}, info)
 }
 
@@ -1,15 +1,23 @@
 package tinygate
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/andyzhou/tinygate/face"
+	"github.com/andyzhou/tinygate/define"
 	"github.com/andyzhou/tinygate/iface"
+	"github.com/andyzhou/tinygate/json"
 	pb "github.com/andyzhou/tinygate/proto"
 	"github.com/andyzhou/tinygate/rpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"log"
 	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
 )
 
 /*
@@ -21,28 +29,85 @@ import (
  * - communicate pass rpc protocol
  */
 
+//authenticator for access auth of gate client
+type Authenticator = iface.IAuthenticator
+
+//message router of stream and general request
+//handler by message id, sub router mounted by service kind
+type Router = iface.IRouter
+
+//interceptor chain of stream data and general request
+//first added is the outermost one, call next to continue
+type (
+	ReqMeta = define.ReqMeta
+	StreamHandler = iface.StreamHandler
+	StreamInterceptor = iface.StreamInterceptor
+	GenHandler = iface.GenHandler
+	GenInterceptor = iface.GenInterceptor
+)
+
+//listen address info
+type listenAddr struct {
+	network string //tcp or unix
+	address string
+}
+
 //service info
 type Service struct {
-	address string //rpc service address
+	addrList []listenAddr //addresses to listen
+	listeners []net.Listener //existing listeners to serve
 	node iface.INode //client node manage instance
 	rpc *rpc.Service //rpc service instance
+	interceptor iface.IInterceptor //inbound interceptor chain
+	codecs iface.ICodecRegistry //codec registry for value api, optional
+	router iface.IRouter //message router, optional
 	service *grpc.Server //g-rpc server
+	tlsLoader *face.TLSLoader //tls loader, optional
+	started bool
+	doneChan chan struct{} //closed when all serve loops exit
+	err error //first error of serve loops
+	sync.RWMutex
 }
 
 //construct
+//if rpc port <= 0, add address or listener before `Start`
 func NewService(rpcPort int) *Service {
 	//self init
-	address := fmt.Sprintf(":%d", rpcPort)
 	this := &Service{
-		address:address,
+		addrList:make([]listenAddr, 0),
+		listeners:make([]net.Listener, 0),
 		node: face.NewNode(),
 		rpc:rpc.NewService(),
+		interceptor:face.NewInterceptor(),
+		doneChan:make(chan struct{}),
+	}
+	if rpcPort > 0 {
+		this.addrList = append(this.addrList, listenAddr{
+			network:"tcp",
+			address:fmt.Sprintf(":%d", rpcPort),
+		})
 	}
 	//set node face for rpc service
 	this.rpc.SetNodeFace(this.node)
+	this.rpc.SetInterceptor(this.interceptor)
 	return this
 }
 
+//construct static token authenticator
+func NewStaticTokenAuth() *face.StaticTokenAuth {
+	return face.NewStaticTokenAuth()
+}
+
+//construct hmac signed token authenticator
+func NewHmacTokenAuth() *face.HmacTokenAuth {
+	return face.NewHmacTokenAuth()
+}
+
+//construct message router
+func NewRouter() Router {
+	return face.NewRouter()
+}
+
 //stop
 func (r *Service) Stop() {
 	defer func() {
@@ -57,12 +122,138 @@ func (r *Service) Stop() {
 	if r.rpc != nil {
 		r.rpc.Quit()
 	}
+	if r.tlsLoader != nil {
+		r.tlsLoader.Quit()
+	}
+}
+
+//shutdown service gracefully
+//mark maintain, wait for in flight general requests and queued
+//stream responses, notify down, then reject new streams, close bind
+//streams, stop and quit client nodes,
+//force stop when context done and return `ErrTimeout` or context error
+func (r *Service) Shutdown(ctx context.Context) error {
+	var (
+		err error
+	)
+
+	//basic check
+	if ctx == nil {
+		return define.ErrInvalidParameter
+	}
+
+	//mark maintain, gate clients stop routing new work
+	r.node.SetStatus(pb.NodeStatus_NODE_MAINTAIN)
+	err = r.waitDrained(ctx)
+
+	//notify down and flush it
+	r.node.SetStatus(pb.NodeStatus_NODE_DOWN)
+	if err == nil {
+		err = r.waitDrained(ctx)
+	}
+
+	//close bind streams
+	r.rpc.Drain()
+
+	//graceful stop, force stop if context done
+	if r.service != nil {
+		stopChan := make(chan struct{})
+		go func() {
+			r.service.GracefulStop()
+			close(stopChan)
+		}()
+		select {
+		case <- stopChan:
+		case <- ctx.Done():
+			//force stop, not wait for running handlers
+			go r.service.Stop()
+			if err == nil {
+				err = r.convertCtxErr(ctx.Err())
+			}
+		}
+	}
+
+	//do some cleanup
+	r.rpc.Quit()
+	r.node.Quit()
+	if r.tlsLoader != nil {
+		r.tlsLoader.Quit()
+	}
+	return err
+}
+
+//set tls option, should be called before `Start`
+//certificate and ca files will be hot reloaded when changed
+func (r *Service) SetTLS(option *TLSOption) error {
+	loader, err := face.NewServerTLSLoader(option)
+	if err != nil {
+		return err
+	}
+	r.tlsLoader = loader
+	return nil
+}
+
+//add address to listen, should be called before `Start`
+//network is `tcp` or `unix`, stale unix socket file will be removed,
+//`Start` fails if the socket file is still served by other process
+func (r *Service) AddAddress(network, address string) error {
+	//basic check
+	if network == "" {
+		network = "tcp"
+	}
+	if address == "" || (network != "tcp" && network != "unix") {
+		return define.ErrInvalidParameter
+	}
+
+	//add with locker
+	r.Lock()
+	defer r.Unlock()
+	if r.started {
+		return errors.New("service has been started")
+	}
+	r.addrList = append(r.addrList, listenAddr{
+		network:network,
+		address:address,
+	})
+	return nil
+}
+
+//add existing listener, should be called before `Start`
+//used for test and socket activation
+func (r *Service) AddListener(listener net.Listener) error {
+	//basic check
+	if listener == nil {
+		return define.ErrInvalidParameter
+	}
+
+	//add with locker
+	r.Lock()
+	defer r.Unlock()
+	if r.started {
+		return errors.New("service has been started")
+	}
+	r.listeners = append(r.listeners, listener)
+	return nil
 }
 
 //start
-func (r *Service) Start() {
+//listen failed will be returned, serve loops run in background
+func (r *Service) Start() error {
 	//create rpc service
-	r.createService()
+	return r.createService()
+}
+
+//get done chan, closed when all serve loops exit
+func (r *Service) Done() <-chan struct{} {
+	return r.doneChan
+}
+
+//get the first error of serve loops
+//nil if stopped by `Stop` or `Shutdown`
+func (r *Service) Err() error {
+	r.RLock()
+	defer r.RUnlock()
+	return r.err
 }
 
 ///////////////////
@@ -70,6 +261,7 @@ func (r *Service) Start() {
 ///////////////////
 
 //send stream data to gate client by remote address
+//address also can be node identity `kind/tag`, which survive reconnect
 func (r *Service) SendStreamDataResp(
 					resp *pb.ByteMessage,
 					address ...string,
@@ -100,6 +292,99 @@ func (r *Service) SendStreamDataResp(
 	return nil
 }
 
+//send stream data to gate client by remote address with context
+//wait for queue room until context done, return the first error
+func (r *Service) SendStreamDataRespCtx(
+					ctx context.Context,
+					resp *pb.ByteMessage,
+					address ...string,
+				) error {
+	var (
+		err error
+	)
+
+	//basic check
+	if address == nil || resp == nil {
+		return define.ErrInvalidParameter
+	}
+
+	//send one by one
+	for _, oneAddr := range address {
+		var subErr error
+		subService := r.node.GetService(oneAddr)
+		if subService == nil {
+			subErr = define.ErrNoClientNode
+		}else{
+			subErr = subService.SendClientRespCtx(ctx, resp)
+		}
+		if subErr != nil && err == nil {
+			err = subErr
+		}
+	}
+	return err
+}
+
+//send value encoded by codec registry to gate client by remote address
+func (r *Service) SendStreamValue(
+					ctx context.Context,
+					messageId uint32,
+					v interface{},
+					address ...string,
+				) error {
+	//basic check
+	if r.codecs == nil {
+		return &define.CodecError{
+			Op:define.CodecOpOfEncode,
+			MessageId:messageId,
+			Err:define.ErrCodecNotFound,
+		}
+	}
+
+	//encode value
+	data, err := r.codecs.Encode(messageId, v)
+	if err != nil {
+		return err
+	}
+
+	//send stream data
+	resp := &pb.ByteMessage{
+		MessageId:messageId,
+		Data:data,
+	}
+	return r.SendStreamDataRespCtx(ctx, resp, address...)
+}
+
+//send stream data to all gate clients with context
+//wait for queue room until context done, return the first error
+func (r *Service) SendStreamDataRespToAllCtx(
+					ctx context.Context,
+					resp *pb.ByteMessage,
+				) error {
+	var (
+		err error
+	)
+
+	//basic check
+	if resp == nil {
+		return define.ErrInvalidParameter
+	}
+
+	//get all sub service
+	allSubService := r.node.GetAllService()
+	if len(allSubService) <= 0 {
+		return define.ErrNoClientNode
+	}
+
+	//send one by one
+	for _, service := range allSubService {
+		subErr := service.SendClientRespCtx(ctx, resp)
+		if subErr != nil && err == nil {
+			err = subErr
+		}
+	}
+	return err
+}
+
 //send stream data to all gate clients
 func (r *Service) SendStreamDataRespToAll(
 					resp *pb.ByteMessage,
@@ -122,10 +407,193 @@ func (r *Service) SendStreamDataRespToAll(
 	return nil
 }
 
+//reply stream call from gate client `Call`
+//req should be the request passed to cb for stream request, or a copy of it,
+//`req.CallId` carries correlation id of the call.
+//return `ErrCallNotFound` if call unknown, replied, given up or expired
+func (r *Service) Reply(req, resp *pb.ByteMessage) error {
+	return r.rpc.Reply(req, resp)
+}
+
+//send request to gate client by remote address, wait for reply
+//gate client should answer by cb set with `Client.SetCBForServiceReq`
+func (r *Service) Request(
+					ctx context.Context,
+					address string,
+					in *pb.ByteMessage,
+				) (*pb.ByteMessage, error) {
+	return r.rpc.Request(ctx, address, in)
+}
+
+//bind player and front end connect id on gate client
+//nodes is service kind -> node tag, if empty pin to current service
+func (r *Service) BindPlayer(
+					address string,
+					connId uint32,
+					playerId int64,
+					nodes map[string]string,
+				) error {
+	return r.sendBindReq(define.NodeOptBind, address, connId, playerId, nodes)
+}
+
+//unbind player on gate client
+//nodes is service kind -> node tag, if empty remove the whole bind
+func (r *Service) UnbindPlayer(
+					address string,
+					connId uint32,
+					playerId int64,
+					nodes map[string]string,
+				) error {
+	return r.sendBindReq(define.NodeOptUnbind, address, connId, playerId, nodes)
+}
+
+//set heart beat option for client nodes
+//client node down after `maxMiss` beats without any data, stream closed,
+//option is sent to client node after node up, slower node follows rate
+func (r *Service) SetHeartBeat(rate time.Duration, maxMiss int) bool {
+	if r.node == nil {
+		return false
+	}
+	return r.node.SetHeartBeat(rate, maxMiss)
+}
+
+//set maintain mode of current service
+//gate clients stop routing new work to maintain service,
+//existing bound or sticky keys still be served
+func (r *Service) SetMaintain(maintain bool) bool {
+	status := pb.NodeStatus_NODE_ACTIVE
+	if maintain {
+		status = pb.NodeStatus_NODE_MAINTAIN
+	}
+	return r.node.SetStatus(status)
+}
+
+//get status of current service
+func (r *Service) GetStatus() pb.NodeStatus {
+	return r.node.GetStatus()
+}
+
+//get authenticated app of gate client by remote address
+//used in stream request cb
+func (r *Service) GetClientApp(remoteAddr string) string {
+	if r.node == nil {
+		return ""
+	}
+	service := r.node.GetService(remoteAddr)
+	if service == nil {
+		return ""
+	}
+	return service.GetApp()
+}
+
+//get node identity `kind/tag` of gate client by remote address
+//return empty if gate client without node tag
+func (r *Service) GetClientNodeId(remoteAddr string) string {
+	if r.node == nil {
+		return ""
+	}
+	service := r.node.GetService(remoteAddr)
+	if service == nil {
+		return ""
+	}
+	return face.GenNodeId(service.GetKind(), service.GetTag())
+}
+
+//get current remote address of gate client by node identity `kind/tag`
+func (r *Service) GetClientAddr(nodeId string) string {
+	if r.node == nil {
+		return ""
+	}
+	return r.node.GetRemoteAddr(nodeId)
+}
+
+//get peer certificate identity of gate client by remote address
+//only for tls mode, used in stream request cb
+func (r *Service) GetClientIdentity(remoteAddr string) string {
+	if r.node == nil {
+		return ""
+	}
+	service := r.node.GetService(remoteAddr)
+	if service == nil {
+		return ""
+	}
+	return service.GetIdentity()
+}
+
+//set access authenticator
+//general request and bind stream will be checked before cb,
+//authenticated app set into `GateReq.Auth.App`
+func (r *Service) SetAuthenticator(authenticator Authenticator) error {
+	return r.rpc.SetAuthenticator(authenticator)
+}
+
 ///////////////////
 //relate cb setup
 ///////////////////
 
+//set codec registry for value api, should be called before `Start`
+//router value handler use its own codec registry
+func (r *Service) SetCodecs(codecs CodecRegistry) error {
+	if codecs == nil {
+		return define.ErrInvalidParameter
+	}
+	r.codecs = codecs
+	return nil
+}
+
+//add inbound stream interceptor
+//run before cb for stream request, include stream call
+func (r *Service) AddStreamInterceptor(interceptor StreamInterceptor) bool {
+	return r.interceptor.AddStream(interceptor)
+}
+
+//add inbound general request interceptor
+//run before cb for general request, include async request
+func (r *Service) AddGenInterceptor(interceptor GenInterceptor) bool {
+	return r.interceptor.AddGen(interceptor)
+}
+
+//set message router
+//router will be used as cb for stream and general request
+func (r *Service) SetRouter(router Router) error {
+	if router == nil {
+		return define.ErrInvalidParameter
+	}
+	err := r.rpc.SetCBForStreamReq(router.DispatchStream)
+	if err != nil {
+		return err
+	}
+	err = r.rpc.SetCBForGenReqCtx(router.DispatchGenCtx)
+	if err != nil {
+		return err
+	}
+	r.router = router
+	return nil
+}
+
+//set response queue option for all gate clients
+//overflow policy applied when queue is full
+func (r *Service) SetQueueOption(option *QueueOption) bool {
+	return r.node.SetQueueOption(option)
+}
+
+//get response queue stat of all gate clients, remote address -> stat
+func (r *Service) GetQueueStats() map[string]QueueStat {
+	result := make(map[string]QueueStat)
+	for addr, service := range r.node.GetAllService() {
+		result[addr] = service.GetQueueStat()
+	}
+	return result
+}
+
+//set cb for response queue crossing high water mark
+//over is false when queue depth fall back to half of high water mark
+func (r *Service) SetCBForQueueHighWater(
+				cb func(remoteAddr string, depth int, over bool) bool,
+			) bool {
+	return r.node.SetCBForQueueHighWater(cb)
+}
+
 //set cb for client node down
 func (r *Service) SetCBForClientNodeDown(cb func(remoteAddr string) bool) bool {
 	if r.node == nil {
@@ -139,47 +607,192 @@ func (r *Service) SetCBForStreamReq(cb func(remoteAddr string, in *pb.ByteMessag
 	return r.rpc.SetCBForStreamReq(cb)
 }
 
+//set cb of front end connect closed on gate client
+//this will not pass the stream request cb
+func (r *Service) SetCBForClientConnClosed(cb func(remoteAddr string, connId uint32) bool) error {
+	return r.rpc.SetCBForClientConnClosed(cb)
+}
+
 //set cb of response for general request from gate client
+//router of general request will be replaced
 func (r *Service) SetCBForGenReq(cb func(req *pb.GateReq) *pb.GateResp) error {
-	return r.rpc.SetCBForGenReq(cb)
+	err := r.rpc.SetCBForGenReq(cb)
+	if err != nil {
+		return err
+	}
+	r.router = nil
+	return nil
 }
 
 /////////////////
 //private func
 /////////////////
 
+//send bind or unbind request to gate client
+func (r *Service) sendBindReq(
+					opt int,
+					address string,
+					connId uint32,
+					playerId int64,
+					nodes map[string]string,
+				) error {
+	//basic check
+	if address == "" || connId == 0 {
+		return errors.New("invalid parameter")
+	}
+
+	//init bind json
+	bindJson := json.NewBindJson()
+	bindJson.Opt = opt
+	bindJson.ConnId = connId
+	bindJson.PlayerId = playerId
+	for kind, tag := range nodes {
+		bindJson.Nodes[kind] = tag
+	}
+
+	//init byte message
+	in := &pb.ByteMessage{
+		MessageId:define.MessageIdOfBindOrUnbind,
+		Data:bindJson.Encode(),
+	}
+	return r.SendStreamDataResp(in, address)
+}
+
+//wait for in flight general requests and queued stream responses
+func (r *Service) waitDrained(ctx context.Context) error {
+	var (
+		ticker = time.NewTicker(time.Millisecond * define.DrainCheckRate)
+	)
+	defer ticker.Stop()
+
+	//loop check
+	for {
+		if r.isDrained() {
+			return nil
+		}
+		select {
+		case <- ticker.C:
+		case <- ctx.Done():
+			return r.convertCtxErr(ctx.Err())
+		}
+	}
+}
+
+//check in flight general requests and queued stream responses
+func (r *Service) isDrained() bool {
+	if r.rpc.GetInFlight() > 0 {
+		return false
+	}
+	for _, service := range r.node.GetAllService() {
+		if service.GetPendingLen() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+//convert context error to sentinel error
+func (r *Service) convertCtxErr(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return define.ErrTimeout
+	}
+	return err
+}
+
 //create rpc service
-func (r *Service) createService() {
-	//try listen tcp port
-	listen, err := net.Listen("tcp", r.address)
-	if err != nil {
-		tips := "Create rpc service failed, error:" + err.Error()
-		log.Println(tips)
-		panic(tips)
+func (r *Service) createService() error {
+	var (
+		wg sync.WaitGroup
+	)
+
+	//check and mark started
+	r.Lock()
+	defer r.Unlock()
+	if r.started {
+		return errors.New("service has been started")
+	}
+	if len(r.addrList) <= 0 && len(r.listeners) <= 0 {
+		return errors.New("no address or listener for service")
 	}
 
+	//try listen all addresses
+	//close opened listeners if failed
+	listeners := make([]net.Listener, 0)
+	for _, addr := range r.addrList {
+		listen, err := r.listen(addr)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			log.Println("Create rpc service failed, error:" + err.Error())
+			return err
+		}
+		listeners = append(listeners, listen)
+	}
+	listeners = append(listeners, r.listeners...)
+
 	//init rpc stat
 	rpcStat := rpc.NewStat(r.node)
 
 	//create rpc server with rpc stat support
-	r.service = grpc.NewServer(
-					grpc.StatsHandler(rpcStat),
-				)
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(rpcStat),
+	}
+	if r.tlsLoader != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(r.tlsLoader.GetServerConfig())))
+	}
+	r.service = grpc.NewServer(opts...)
 
 	//register call back
 	pb.RegisterGateServiceServer(r.service, r.rpc)
+	r.started = true
 
 	//begin rpc service
-	go r.beginService(listen)
+	for _, listen := range listeners {
+		wg.Add(1)
+		go r.beginService(listen, &wg)
+	}
+
+	//close done chan after all serve loops exit
+	go func() {
+		wg.Wait()
+		close(r.doneChan)
+	}()
+	return nil
+}
+
+//listen address
+func (r *Service) listen(addr listenAddr) (net.Listener, error) {
+	//remove stale unix socket file
+	//socket is live if dial succeeded, only refused one is stale
+	if addr.network == "unix" {
+		stat, err := os.Stat(addr.address)
+		if err == nil && stat.Mode() & os.ModeSocket != 0 {
+			conn, err := net.DialTimeout(addr.network, addr.address, time.Second)
+			if err == nil {
+				conn.Close()
+				return nil, fmt.Errorf("unix socket %s is in use", addr.address)
+			}
+			if errors.Is(err, syscall.ECONNREFUSED) {
+				os.Remove(addr.address)
+			}
+		}
+	}
+	return net.Listen(addr.network, addr.address)
 }
 
 //begin rpc service
-func (r *Service) beginService(listen net.Listener) {
+func (r *Service) beginService(listen net.Listener, wg *sync.WaitGroup) {
+	defer wg.Done()
+
 	//service listen
 	err := r.service.Serve(listen)
 	if err != nil {
-		tips := "Failed for rpc service, error:" + err.Error()
-		log.Println(tips)
-		panic(tips)
+		log.Println("Failed for rpc service, error:" + err.Error())
+		r.Lock()
+		if r.err == nil {
+			r.err = err
+		}
+		r.Unlock()
 	}
 }
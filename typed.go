@@ -0,0 +1,218 @@
+package tinygate
+
+import (
+	"context"
+	"errors"
+	"github.com/andyzhou/tinygate/define"
+	"github.com/andyzhou/tinygate/face"
+	"github.com/andyzhou/tinygate/iface"
+	pb "github.com/andyzhou/tinygate/proto"
+	"reflect"
+)
+
+/*
+ * typed api, base on go generics
+ * - typed handler of general request for sub service side
+ * - typed call of general request for gate client side
+ * - use codec of registry by message id, json codec by default
+ */
+
+//context key of general request
+type gateReqKey struct{}
+
+//register typed handler of general request by message id
+//use router of service, or set new one for general request,
+//return `ErrGenCBExists` if cb for general request set without router,
+//handler ctx derived from request ctx,
+//handler error is replied with `HandlerErrCode`, or code of `RespError`
+func Handle[Req, Resp any](
+			svc *Service,
+			messageId uint32,
+			handler func(ctx context.Context, req Req) (Resp, error),
+		) error {
+	//basic check
+	if svc == nil || handler == nil {
+		return define.ErrInvalidParameter
+	}
+
+	//init router for general request if not set
+	//cb for stream request is kept, other cb for general request is not replaced
+	if svc.router == nil {
+		if svc.rpc.HasCBForGenReq() {
+			return define.ErrGenCBExists
+		}
+		router := NewRouter()
+		err := svc.rpc.SetCBForGenReqCtx(router.DispatchGenCtx)
+		if err != nil {
+			return err
+		}
+		svc.router = router
+	}
+
+	//register general handler
+	svc.router.HandleGenCtx(messageId, func(ctx context.Context, in *pb.GateReq) *pb.GateResp {
+		//init response
+		resp := &pb.GateResp{
+			Service:in.Service,
+			MessageId:in.MessageId,
+		}
+		codec := getCodec(svc.codecs, in.MessageId)
+
+		//decode request
+		req, err := decodeValue[Req](codec, in.MessageId, in.Data)
+		if err != nil {
+			resp.ErrorCode = define.CodecErrCode
+			resp.ErrorMessage = err.Error()
+			return resp
+		}
+
+		//call handler
+		ctx = context.WithValue(ctx, gateReqKey{}, in)
+		result, err := handler(ctx, req)
+		if err != nil {
+			var respErr *define.RespError
+			if errors.As(err, &respErr) {
+				resp.ErrorCode = respErr.Code
+				resp.ErrorMessage = respErr.Message
+			}else{
+				resp.ErrorCode = define.HandlerErrCode
+				resp.ErrorMessage = err.Error()
+			}
+			return resp
+		}
+
+		//encode result
+		resp.Data, err = encodeValue(codec, in.MessageId, result)
+		if err != nil {
+			resp.ErrorCode = define.CodecErrCode
+			resp.ErrorMessage = err.Error()
+		}
+		return resp
+	})
+	return nil
+}
+
+//get general request in typed handler
+func GateReqFromContext(ctx context.Context) *pb.GateReq {
+	if ctx == nil {
+		return nil
+	}
+	in, _ := ctx.Value(gateReqKey{}).(*pb.GateReq)
+	return in
+}
+
+//send typed general request to one kind sub gate/service
+func Call[Req, Resp any](
+			client *Client,
+			kind string,
+			messageId uint32,
+			req Req,
+		) (Resp, error) {
+	return CallCtx[Req, Resp](context.Background(), client, kind, messageId, req)
+}
+
+//send typed general request to one kind sub gate/service with context
+//return `RespError` if response with error code
+func CallCtx[Req, Resp any](
+			ctx context.Context,
+			client *Client,
+			kind string,
+			messageId uint32,
+			req Req,
+		) (Resp, error) {
+	var (
+		resp Resp
+	)
+
+	//basic check
+	if client == nil || kind == "" {
+		return resp, define.ErrInvalidParameter
+	}
+
+	//encode request
+	codec := getCodec(client.client.GetCodecs(), messageId)
+	data, err := encodeValue(codec, messageId, req)
+	if err != nil {
+		return resp, err
+	}
+
+	//send general request
+	gateResp, err := client.SendGenReqCtx(ctx, &pb.GateReq{
+		Service:kind,
+		MessageId:messageId,
+		Data:data,
+	})
+	if err != nil {
+		return resp, err
+	}
+	if gateResp.ErrorCode != 0 {
+		return resp, &define.RespError{
+			Code:gateResp.ErrorCode,
+			Message:gateResp.ErrorMessage,
+		}
+	}
+
+	//decode response
+	return decodeValue[Resp](codec, messageId, gateResp.Data)
+}
+
+////////////////
+//private func
+////////////////
+
+//get codec of message id from registry, json codec by default
+func getCodec(codecs iface.ICodecRegistry, messageId uint32) iface.ICodec {
+	if codecs != nil {
+		codec := codecs.GetCodec(messageId)
+		if codec != nil {
+			return codec
+		}
+	}
+	return face.NewJsonCodec()
+}
+
+//encode typed value
+func encodeValue(codec iface.ICodec, messageId uint32, v interface{}) ([]byte, error) {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return nil, &define.CodecError{
+			Op:define.CodecOpOfEncode,
+			MessageId:messageId,
+			Codec:codec.Name(),
+			Err:err,
+		}
+	}
+	return data, nil
+}
+
+//decode typed value
+//pointer type will be allocated, like protobuf message
+func decodeValue[T any](codec iface.ICodec, messageId uint32, data []byte) (T, error) {
+	var (
+		v T
+		target interface{} = &v
+	)
+
+	//allocate pointer type
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() == reflect.Ptr {
+		ptr := reflect.New(typ.Elem())
+		v = ptr.Interface().(T)
+		target = v
+	}
+	if len(data) <= 0 {
+		return v, nil
+	}
+
+	//decode data
+	err := codec.Unmarshal(data, target)
+	if err != nil {
+		return v, &define.CodecError{
+			Op:define.CodecOpOfDecode,
+			MessageId:messageId,
+			Codec:codec.Name(),
+			Err:err,
+		}
+	}
+	return v, nil
+}